@@ -0,0 +1,139 @@
+package admin
+
+import "time"
+
+// SearchRequest matches q against ids, emails, usernames and names of users,
+// or ids, names and slugs of orgs. An empty q lists everything.
+type SearchRequest struct {
+	Query         string `json:"-" validate:"max=200"`
+	Limit         int    `json:"-" validate:"min=0,max=100"`
+	Offset        int    `json:"-" validate:"min=0"`
+	CurrentUserID int    `json:"-" validate:"required"`
+}
+
+type TargetRequest struct {
+	ID            int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-" validate:"required"`
+}
+
+type SuspendRequest struct {
+	Reason        string `json:"reason" validate:"required,max=500"`
+	ID            int    `json:"-" validate:"required"`
+	CurrentUserID int    `json:"-" validate:"required"`
+}
+
+// ImpersonateRequest asks for a token to act as the user. The reason is
+// kept in the audit log.
+type ImpersonateRequest struct {
+	Reason        string `json:"reason" validate:"required,max=500"`
+	ID            int    `json:"-" validate:"required"`
+	CurrentUserID int    `json:"-" validate:"required"`
+}
+
+// RepairMembershipRequest creates or fixes the user's membership in the org.
+// Only the fields that are present change; a new membership needs both.
+// Leaving the invited status discards the user's outstanding invitations to
+// the org.
+type RepairMembershipRequest struct {
+	RoleID        *int    `json:"roleId" validate:"omitempty,roleid"`
+	Status        *string `json:"status" validate:"omitempty,oneof=active inactive invited pending rejected"`
+	UserID        int     `json:"-" validate:"required"`
+	OrgID         int     `json:"-" validate:"required"`
+	CurrentUserID int     `json:"-" validate:"required"`
+}
+
+type MembershipRequest struct {
+	UserID        int `json:"-" validate:"required"`
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-" validate:"required"`
+}
+
+type UserResponse struct {
+	ID               int        `json:"id"`
+	Email            string     `json:"email"`
+	Username         *string    `json:"username"`
+	FirstName        string     `json:"firstName"`
+	LastName         string     `json:"lastName"`
+	VerifiedEmail    bool       `json:"verifiedEmail"`
+	PlatformRole     string     `json:"platformRole"`
+	SuspendedAt      *time.Time `json:"suspendedAt"`
+	SuspensionReason string     `json:"suspensionReason"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+type UsersResponse struct {
+	Users []UserResponse `json:"users"`
+	Total int64          `json:"total"`
+}
+
+type MembershipResponse struct {
+	OrgID          int        `json:"orgId"`
+	OrgName        string     `json:"orgName"`
+	OrgSlug        string     `json:"orgSlug"`
+	RoleID         int        `json:"roleId"`
+	RoleName       string     `json:"roleName"`
+	Status         string     `json:"status"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+}
+
+type InvitationResponse struct {
+	OrgID     int       `json:"orgId"`
+	RoleID    *int      `json:"roleId"`
+	Status    string    `json:"status"`
+	CreatedBy *int      `json:"createdBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ImpersonationResponse is a bearer token for acting as the user until
+// ExpiresAt. Requests made with it are audited under ImpersonationID.
+type ImpersonationResponse struct {
+	Token           string    `json:"token"`
+	ImpersonationID string    `json:"impersonationId"`
+	UserID          int       `json:"userId"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// UserDetailResponse lists every membership of the user, whatever its status,
+// and the invitations that are still open, expired or not.
+type UserDetailResponse struct {
+	UserResponse
+	Memberships []MembershipResponse `json:"memberships"`
+	Invitations []InvitationResponse `json:"invitations"`
+}
+
+type OrgResponse struct {
+	ID               int        `json:"id"`
+	Name             string     `json:"name"`
+	Slug             string     `json:"slug"`
+	Size             string     `json:"size"`
+	ParentID         *int       `json:"parentId"`
+	MemberCount      int        `json:"memberCount"`
+	SuspendedAt      *time.Time `json:"suspendedAt"`
+	SuspensionReason string     `json:"suspensionReason"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	DeletedAt        *time.Time `json:"deletedAt"`
+}
+
+type OrgsResponse struct {
+	Orgs  []OrgResponse `json:"orgs"`
+	Total int64         `json:"total"`
+}
+
+type OrgMemberResponse struct {
+	UserID   int    `json:"userId"`
+	Email    string `json:"email"`
+	RoleID   int    `json:"roleId"`
+	RoleName string `json:"roleName"`
+	Status   string `json:"status"`
+}
+
+// OrgDetailResponse lists every membership of the org, whatever its status.
+type OrgDetailResponse struct {
+	OrgResponse
+	Members []OrgMemberResponse `json:"members"`
+}
+
+type StatusResponse struct {
+	Status bool `json:"status"`
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"org-service/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes mounts the platform admin API. It is outside any org: only
+// users with a platform role get through, and not while impersonating.
+func RegisterRoutes(router fiber.Router, adminHttpTransport AdminHTTPTransport, authMiddleware func(c *fiber.Ctx) error, rbac middleware.RBAC) {
+	adminRouter := router.Group("/admin", authMiddleware, middleware.NotWhileImpersonating, rbac.PlatformAdmin)
+
+	adminRouter.Get("/users", adminHttpTransport.SearchUsers)
+	adminRouter.Get("/users/:userId", adminHttpTransport.GetUser)
+	adminRouter.Post("/users/:userId/suspend", adminHttpTransport.SuspendUser)
+	adminRouter.Post("/users/:userId/unsuspend", adminHttpTransport.UnsuspendUser)
+	adminRouter.Post("/users/:userId/verify-email", adminHttpTransport.VerifyUserEmail)
+	adminRouter.Post("/users/:userId/impersonate", adminHttpTransport.ImpersonateUser)
+	adminRouter.Put("/users/:userId/memberships/:orgId", adminHttpTransport.RepairMembership)
+	adminRouter.Delete("/users/:userId/memberships/:orgId", adminHttpTransport.RemoveMembership)
+
+	adminRouter.Get("/orgs", adminHttpTransport.SearchOrgs)
+	adminRouter.Get("/orgs/:orgId", adminHttpTransport.GetOrg)
+	adminRouter.Post("/orgs/:orgId/suspend", adminHttpTransport.SuspendOrg)
+	adminRouter.Post("/orgs/:orgId/unsuspend", adminHttpTransport.UnsuspendOrg)
+}
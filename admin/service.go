@@ -0,0 +1,731 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"org-service/audit"
+	"org-service/helper"
+	"org-service/jwtauth"
+	orgsvc "org-service/org"
+	"org-service/teams"
+	"org-service/tokens"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Audit actions recorded by the admin service. Lookups are recorded too:
+// support staff reading a customer's data should leave a trace.
+const (
+	AuditActionUsersSearched      = "admin.users.searched"
+	AuditActionUserViewed         = "admin.user.viewed"
+	AuditActionUserSuspended      = "admin.user.suspended"
+	AuditActionUserUnsuspended    = "admin.user.unsuspended"
+	AuditActionUserEmailVerified  = "admin.user.email_verified"
+	AuditActionUserImpersonated   = "admin.user.impersonated"
+	AuditActionMembershipRepaired = "admin.membership.repaired"
+	AuditActionMembershipRemoved  = "admin.membership.removed"
+	AuditActionOrgsSearched       = "admin.orgs.searched"
+	AuditActionOrgViewed          = "admin.org.viewed"
+	AuditActionOrgSuspended       = "admin.org.suspended"
+	AuditActionOrgUnsuspended     = "admin.org.unsuspended"
+)
+
+const (
+	defaultSearchLimit = 20
+	statusInvited      = "invited"
+)
+
+const userColumns = "users.id, users.email, users.username, users.first_name, users.last_name, users.verified_email, " +
+	"users.platform_role, users.suspended_at, users.suspension_reason, users.created_at"
+
+const orgColumns = "orgs.id, orgs.name, orgs.slug, orgs.size, orgs.parent_id, orgs.suspended_at, orgs.suspension_reason, " +
+	"orgs.created_at, orgs.deleted_at, (SELECT COUNT(*) FROM user_org_roles members WHERE members.org_id = orgs.id AND members.status = 'active') AS member_count"
+
+type adminApi struct {
+	db           *gorm.DB
+	impersonator *jwtauth.Impersonator
+	logger       *slog.Logger
+	validate     *helper.Validator
+}
+
+type AdminAPI interface {
+	SearchUsers(ctx context.Context, req *SearchRequest) (*UsersResponse, error)
+	GetUser(ctx context.Context, req *TargetRequest) (*UserDetailResponse, error)
+	SuspendUser(ctx context.Context, req *SuspendRequest) (*UserResponse, error)
+	UnsuspendUser(ctx context.Context, req *TargetRequest) (*UserResponse, error)
+	VerifyUserEmail(ctx context.Context, req *TargetRequest) (*UserResponse, error)
+	ImpersonateUser(ctx context.Context, req *ImpersonateRequest) (*ImpersonationResponse, error)
+	RepairMembership(ctx context.Context, req *RepairMembershipRequest) (*MembershipResponse, error)
+	RemoveMembership(ctx context.Context, req *MembershipRequest) (*StatusResponse, error)
+	SearchOrgs(ctx context.Context, req *SearchRequest) (*OrgsResponse, error)
+	GetOrg(ctx context.Context, req *TargetRequest) (*OrgDetailResponse, error)
+	SuspendOrg(ctx context.Context, req *SuspendRequest) (*OrgResponse, error)
+	UnsuspendOrg(ctx context.Context, req *TargetRequest) (*OrgResponse, error)
+}
+
+func NewAdminService(db *gorm.DB, impersonator *jwtauth.Impersonator, logger *slog.Logger) AdminAPI {
+	return &adminApi{db: db, impersonator: impersonator, logger: logger, validate: helper.NewValidator(db)}
+}
+
+// @Summary      	SearchUsers
+// @Description		Searches users across all orgs by id, email, username or name. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			q								query		string			false	"Search text"
+// @Param			limit							query		int				false	"Page size, at most 100 (default 20)"
+// @Param			offset							query		int				false	"Results to skip"
+// @Success			200								{object}	UsersResponse
+// @Router			/api/admin/users				[GET]
+func (s *adminApi) SearchUsers(ctx context.Context, req *SearchRequest) (*UsersResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.Query = strings.TrimSpace(req.Query)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	query := db.Table(orgsvc.UserTableName)
+	if req.Query != "" {
+		pattern := likePattern(req.Query)
+		cond := db.Where("users.email ILIKE ? OR users.username ILIKE ? OR (users.first_name || ' ' || users.last_name) ILIKE ?", pattern, pattern, pattern)
+		if id, err := strconv.Atoi(req.Query); err == nil {
+			cond = cond.Or("users.id = ?", id)
+		}
+		query = query.Where(cond)
+	}
+
+	res := &UsersResponse{Users: []UserResponse{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := query.Session(&gorm.Session{}).Count(&res.Total).Error; err != nil {
+			return err
+		}
+		err := query.Select(userColumns).Order("users.id").Limit(pageSize(req.Limit)).Offset(req.Offset).Scan(&res.Users).Error
+		if err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, nil, AuditActionUsersSearched, "user", "",
+			map[string]interface{}{"q": req.Query, "results": len(res.Users)})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return res, nil
+}
+
+// @Summary      	GetUser
+// @Description		Returns a user with all their memberships and open invitations. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			userId							path		int				true	"User ID"
+// @Success			200								{object}	UserDetailResponse
+// @Router			/api/admin/users/{userId}		[GET]
+func (s *adminApi) GetUser(ctx context.Context, req *TargetRequest) (*UserDetailResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	res := &UserDetailResponse{Memberships: []MembershipResponse{}, Invitations: []InvitationResponse{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findUser(tx, req.ID, &res.UserResponse); err != nil {
+			return err
+		}
+		err := membershipsQuery(tx).
+			Where("user_org_roles.user_id = ?", req.ID).
+			Order("orgs.name").
+			Scan(&res.Memberships).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Table(tokens.TableName).
+			Select("org_id, role_id, status, created_by, expires_at, created_at").
+			Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", tokens.PurposeInvitation, req.ID).
+			Order("created_at DESC").
+			Scan(&res.Invitations).Error
+		if err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, nil, AuditActionUserViewed, "user", strconv.Itoa(req.ID), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// @Summary      	SuspendUser
+// @Description		Suspends a user: all their requests are refused until they are unsuspended. Platform admins only; they cannot suspend themselves.
+// @Tags			Admin
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			userId							path		int				true	"User ID"
+// @Param			SuspendRequest					body		SuspendRequest	true	"SuspendRequest"
+// @Success			200								{object}	UserResponse
+// @Router			/api/admin/users/{userId}/suspend	[POST]
+func (s *adminApi) SuspendUser(ctx context.Context, req *SuspendRequest) (*UserResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.ID == req.CurrentUserID {
+		return nil, helper.Forbidden("permission_denied", "you cannot suspend yourself")
+	}
+
+	var user UserResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), req.ID, &user); err != nil {
+			return err
+		}
+		if user.SuspendedAt != nil {
+			return helper.Conflict("already_suspended", "user is already suspended")
+		}
+
+		now := time.Now()
+		err := tx.Table(orgsvc.UserTableName).Where("id = ?", req.ID).
+			Updates(map[string]interface{}{"suspended_at": now, "suspension_reason": req.Reason}).Error
+		if err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspensionReason = &now, req.Reason
+
+		return record(tx, req.CurrentUserID, nil, AuditActionUserSuspended, "user", strconv.Itoa(req.ID),
+			map[string]interface{}{"reason": req.Reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("user suspended", "user_id", req.ID, "suspended_by", req.CurrentUserID)
+
+	return &user, nil
+}
+
+// @Summary      	UnsuspendUser
+// @Description		Lifts a user's suspension. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			userId							path		int				true	"User ID"
+// @Success			200								{object}	UserResponse
+// @Router			/api/admin/users/{userId}/unsuspend	[POST]
+func (s *adminApi) UnsuspendUser(ctx context.Context, req *TargetRequest) (*UserResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user UserResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), req.ID, &user); err != nil {
+			return err
+		}
+		if user.SuspendedAt == nil {
+			return helper.Conflict("not_suspended", "user is not suspended")
+		}
+
+		err := tx.Table(orgsvc.UserTableName).Where("id = ?", req.ID).
+			Updates(map[string]interface{}{"suspended_at": nil, "suspension_reason": ""}).Error
+		if err != nil {
+			return err
+		}
+		reason := user.SuspensionReason
+		user.SuspendedAt, user.SuspensionReason = nil, ""
+
+		return record(tx, req.CurrentUserID, nil, AuditActionUserUnsuspended, "user", strconv.Itoa(req.ID),
+			map[string]interface{}{"suspensionReason": reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("user unsuspended", "user_id", req.ID, "unsuspended_by", req.CurrentUserID)
+
+	return &user, nil
+}
+
+// @Summary      	VerifyUserEmail
+// @Description		Marks the user's email address as verified without a verification link, e.g. when mail does not reach them. Outstanding verification links stop working. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			userId							path		int				true	"User ID"
+// @Success			200								{object}	UserResponse
+// @Router			/api/admin/users/{userId}/verify-email	[POST]
+func (s *adminApi) VerifyUserEmail(ctx context.Context, req *TargetRequest) (*UserResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user UserResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), req.ID, &user); err != nil {
+			return err
+		}
+		if user.VerifiedEmail {
+			return helper.Conflict("email_already_verified", "email is already verified")
+		}
+
+		if err := tx.Table(orgsvc.UserTableName).Where("id = ?", req.ID).Update("verified_email", true).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", tokens.PurposeEmailVerification, req.ID).
+			Delete(&tokens.Token{}).Error; err != nil {
+			return err
+		}
+		user.VerifiedEmail = true
+
+		return record(tx, req.CurrentUserID, nil, AuditActionUserEmailVerified, "user", strconv.Itoa(req.ID),
+			map[string]interface{}{"email": user.Email})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// @Summary      	ImpersonateUser
+// @Description		Issues a short-lived token for acting as the user, e.g. to reproduce a problem they report. Requests made with it are audited, and it cannot change passwords, emails, roles or API keys. Superadmins and suspended users cannot be impersonated. Platform admins only.
+// @Tags			Admin
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string				true	"Authorization Key(e.g Bearer key)"
+// @Param			userId							path		int					true	"User ID"
+// @Param			ImpersonateRequest				body		ImpersonateRequest	true	"ImpersonateRequest"
+// @Success			200								{object}	ImpersonationResponse
+// @Router			/api/admin/users/{userId}/impersonate	[POST]
+func (s *adminApi) ImpersonateUser(ctx context.Context, req *ImpersonateRequest) (*ImpersonationResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+	if !s.impersonator.Enabled() {
+		return nil, helper.Forbidden("impersonation_disabled", "impersonation is not configured")
+	}
+	if req.ID == req.CurrentUserID {
+		return nil, helper.Forbidden("permission_denied", "you cannot impersonate yourself")
+	}
+
+	var res *ImpersonationResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user UserResponse
+		if err := findUser(tx, req.ID, &user); err != nil {
+			return err
+		}
+		if user.PlatformRole != "" {
+			return helper.Forbidden("permission_denied", "platform admins cannot be impersonated")
+		}
+		if user.SuspendedAt != nil {
+			return helper.Conflict("user_suspended", "suspended users cannot be impersonated")
+		}
+
+		token, err := s.impersonator.Issue(req.ID, req.CurrentUserID)
+		if err != nil {
+			return err
+		}
+		res = &ImpersonationResponse{Token: token.Token, ImpersonationID: token.ID, UserID: req.ID, ExpiresAt: token.ExpiresAt}
+
+		return record(tx, req.CurrentUserID, nil, AuditActionUserImpersonated, "user", strconv.Itoa(req.ID),
+			map[string]interface{}{"reason": req.Reason, "impersonationId": token.ID, "expiresAt": token.ExpiresAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("user impersonation started", "user_id", req.ID, "actor_id", req.CurrentUserID, "impersonation_id", res.ImpersonationID)
+
+	return res, nil
+}
+
+// @Summary      	RepairMembership
+// @Description		Creates or corrects a user's membership in an org, e.g. to activate a stuck invitation. Changing the status away from invited discards the user's open invitations to the org. Platform admins only.
+// @Tags			Admin
+// @Accept			json
+// @Produce			json
+// @Param			Authorization							header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			userId									path		int						true	"User ID"
+// @Param			orgId									path		int						true	"Org ID"
+// @Param			RepairMembershipRequest					body		RepairMembershipRequest	true	"RepairMembershipRequest"
+// @Success			200										{object}	MembershipResponse
+// @Router			/api/admin/users/{userId}/memberships/{orgId}	[PUT]
+func (s *adminApi) RepairMembership(ctx context.Context, req *RepairMembershipRequest) (*MembershipResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var membership MembershipResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user UserResponse
+		if err := findUser(tx, req.UserID, &user); err != nil {
+			return err
+		}
+		var org OrgResponse
+		if err := findOrg(tx, req.OrgID, &org); err != nil {
+			return err
+		}
+
+		var existing orgsvc.UserOrgRole
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).
+			First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		created := errors.Is(err, gorm.ErrRecordNotFound)
+
+		changes := map[string]interface{}{}
+		if created {
+			if req.RoleID == nil {
+				return helper.FieldRequired("roleId")
+			}
+			if req.Status == nil {
+				return helper.FieldRequired("status")
+			}
+			changes["roleId"] = audit.Change{Old: nil, New: *req.RoleID}
+			changes["status"] = audit.Change{Old: nil, New: *req.Status}
+			row := orgsvc.UserOrgRole{UserID: req.UserID, OrgID: req.OrgID, RoleID: *req.RoleID, Status: *req.Status}
+			if err := tx.Table(orgsvc.UserOrgRoleTableName).Omit(clause.Associations).Create(&row).Error; err != nil {
+				return err
+			}
+		} else {
+			updates := map[string]interface{}{}
+			if req.RoleID != nil && *req.RoleID != existing.RoleID {
+				changes["roleId"] = audit.Change{Old: existing.RoleID, New: *req.RoleID}
+				updates["role_id"] = *req.RoleID
+			}
+			if req.Status != nil && *req.Status != existing.Status {
+				changes["status"] = audit.Change{Old: existing.Status, New: *req.Status}
+				updates["status"] = *req.Status
+			}
+			if len(updates) > 0 {
+				err := tx.Table(orgsvc.UserOrgRoleTableName).
+					Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).
+					Updates(updates).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(changes) > 0 {
+			if req.Status != nil && *req.Status != statusInvited {
+				if err := deleteInvitations(tx, req.UserID, req.OrgID); err != nil {
+					return err
+				}
+			}
+			orgID := req.OrgID
+			changes["userId"] = req.UserID
+			changes["created"] = created
+			err := record(tx, req.CurrentUserID, &orgID, AuditActionMembershipRepaired, "user", strconv.Itoa(req.UserID), changes)
+			if err != nil {
+				return err
+			}
+		}
+
+		return membershipsQuery(tx).
+			Where("user_org_roles.user_id = ? AND user_org_roles.org_id = ?", req.UserID, req.OrgID).
+			Scan(&membership).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("membership repaired", "user_id", req.UserID, "org_id", req.OrgID, "repaired_by", req.CurrentUserID)
+
+	return &membership, nil
+}
+
+// @Summary      	RemoveMembership
+// @Description		Removes a user from an org, with their team memberships and open invitations there. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization							header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			userId									path		int				true	"User ID"
+// @Param			orgId									path		int				true	"Org ID"
+// @Success			200										{object}	StatusResponse
+// @Router			/api/admin/users/{userId}/memberships/{orgId}	[DELETE]
+func (s *adminApi) RemoveMembership(ctx context.Context, req *MembershipRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing orgsvc.UserOrgRole
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).
+			First(&existing).Error
+		if err != nil {
+			return helper.NotFoundIfMissing(err, "membership_not_found", "user is not a member of this organization")
+		}
+
+		if err := tx.Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).Delete(&orgsvc.UserOrgRole{}).Error; err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ? AND team_id IN (?)", req.UserID, tx.Model(&teams.Team{}).Select("id").Where("org_id = ?", req.OrgID)).
+			Delete(&teams.TeamMember{}).Error
+		if err != nil {
+			return err
+		}
+		if err := deleteInvitations(tx, req.UserID, req.OrgID); err != nil {
+			return err
+		}
+		err = tx.Table(orgsvc.UserTableName).Where("id = ? AND default_org_id = ?", req.UserID, req.OrgID).
+			Update("default_org_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		orgID := req.OrgID
+		return record(tx, req.CurrentUserID, &orgID, AuditActionMembershipRemoved, "user", strconv.Itoa(req.UserID),
+			map[string]interface{}{"userId": req.UserID, "roleId": existing.RoleID, "status": existing.Status})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("membership removed", "user_id", req.UserID, "org_id", req.OrgID, "removed_by", req.CurrentUserID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	SearchOrgs
+// @Description		Searches all orgs, including suspended and deleted ones, by id, name or slug. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			q								query		string			false	"Search text"
+// @Param			limit							query		int				false	"Page size, at most 100 (default 20)"
+// @Param			offset							query		int				false	"Results to skip"
+// @Success			200								{object}	OrgsResponse
+// @Router			/api/admin/orgs					[GET]
+func (s *adminApi) SearchOrgs(ctx context.Context, req *SearchRequest) (*OrgsResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.Query = strings.TrimSpace(req.Query)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	query := db.Table(orgsvc.OrgTableName)
+	if req.Query != "" {
+		pattern := likePattern(req.Query)
+		cond := db.Where("orgs.name ILIKE ? OR orgs.slug ILIKE ?", pattern, pattern)
+		if id, err := strconv.Atoi(req.Query); err == nil {
+			cond = cond.Or("orgs.id = ?", id)
+		}
+		query = query.Where(cond)
+	}
+
+	res := &OrgsResponse{Orgs: []OrgResponse{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := query.Session(&gorm.Session{}).Count(&res.Total).Error; err != nil {
+			return err
+		}
+		err := query.Select(orgColumns).Order("orgs.id").Limit(pageSize(req.Limit)).Offset(req.Offset).Scan(&res.Orgs).Error
+		if err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, nil, AuditActionOrgsSearched, "org", "",
+			map[string]interface{}{"q": req.Query, "results": len(res.Orgs)})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orgs: %w", err)
+	}
+	return res, nil
+}
+
+// @Summary      	GetOrg
+// @Description		Returns an org with all its memberships, whatever their status. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"Org ID"
+// @Success			200								{object}	OrgDetailResponse
+// @Router			/api/admin/orgs/{orgId}			[GET]
+func (s *adminApi) GetOrg(ctx context.Context, req *TargetRequest) (*OrgDetailResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	res := &OrgDetailResponse{Members: []OrgMemberResponse{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findOrg(tx, req.ID, &res.OrgResponse); err != nil {
+			return err
+		}
+		err := tx.Table(orgsvc.UserOrgRoleTableName).
+			Select("user_org_roles.user_id, users.email, user_org_roles.role_id, COALESCE(roles.name, '') AS role_name, user_org_roles.status").
+			Joins("JOIN users ON users.id = user_org_roles.user_id").
+			Joins("LEFT JOIN roles ON roles.id = user_org_roles.role_id").
+			Where("user_org_roles.org_id = ?", req.ID).
+			Order("user_org_roles.role_id, users.email").
+			Scan(&res.Members).Error
+		if err != nil {
+			return err
+		}
+		orgID := req.ID
+		return record(tx, req.CurrentUserID, &orgID, AuditActionOrgViewed, "org", strconv.Itoa(req.ID), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// @Summary      	SuspendOrg
+// @Description		Suspends an org: its members and API keys cannot access it until it is unsuspended. Platform admins only.
+// @Tags			Admin
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"Org ID"
+// @Param			SuspendRequest					body		SuspendRequest	true	"SuspendRequest"
+// @Success			200								{object}	OrgResponse
+// @Router			/api/admin/orgs/{orgId}/suspend	[POST]
+func (s *adminApi) SuspendOrg(ctx context.Context, req *SuspendRequest) (*OrgResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var org OrgResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findOrg(tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: orgsvc.OrgTableName}}), req.ID, &org); err != nil {
+			return err
+		}
+		if org.SuspendedAt != nil {
+			return helper.Conflict("already_suspended", "org is already suspended")
+		}
+
+		now := time.Now()
+		err := tx.Table(orgsvc.OrgTableName).Where("id = ?", req.ID).
+			Updates(map[string]interface{}{"suspended_at": now, "suspension_reason": req.Reason}).Error
+		if err != nil {
+			return err
+		}
+		org.SuspendedAt, org.SuspensionReason = &now, req.Reason
+
+		orgID := req.ID
+		return record(tx, req.CurrentUserID, &orgID, AuditActionOrgSuspended, "org", strconv.Itoa(req.ID),
+			map[string]interface{}{"reason": req.Reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("org suspended", "org_id", req.ID, "suspended_by", req.CurrentUserID)
+
+	return &org, nil
+}
+
+// @Summary      	UnsuspendOrg
+// @Description		Lifts an org's suspension. Platform admins only.
+// @Tags			Admin
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"Org ID"
+// @Success			200								{object}	OrgResponse
+// @Router			/api/admin/orgs/{orgId}/unsuspend	[POST]
+func (s *adminApi) UnsuspendOrg(ctx context.Context, req *TargetRequest) (*OrgResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var org OrgResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := findOrg(tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: orgsvc.OrgTableName}}), req.ID, &org); err != nil {
+			return err
+		}
+		if org.SuspendedAt == nil {
+			return helper.Conflict("not_suspended", "org is not suspended")
+		}
+
+		err := tx.Table(orgsvc.OrgTableName).Where("id = ?", req.ID).
+			Updates(map[string]interface{}{"suspended_at": nil, "suspension_reason": ""}).Error
+		if err != nil {
+			return err
+		}
+		reason := org.SuspensionReason
+		org.SuspendedAt, org.SuspensionReason = nil, ""
+
+		orgID := req.ID
+		return record(tx, req.CurrentUserID, &orgID, AuditActionOrgUnsuspended, "org", strconv.Itoa(req.ID),
+			map[string]interface{}{"suspensionReason": reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("org unsuspended", "org_id", req.ID, "unsuspended_by", req.CurrentUserID)
+
+	return &org, nil
+}
+
+// Private helper funcs
+
+func record(tx *gorm.DB, actorUserID int, orgID *int, action, targetType, targetID string, details map[string]interface{}) error {
+	return audit.Record(tx, &audit.Event{
+		OrgID:       orgID,
+		ActorUserID: &actorUserID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Details:     details,
+	})
+}
+
+func findUser(tx *gorm.DB, userID int, user *UserResponse) error {
+	result := tx.Table(orgsvc.UserTableName).Select(userColumns).Where("users.id = ?", userID).Scan(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("user_not_found", "user not found")
+	}
+	return nil
+}
+
+func findOrg(tx *gorm.DB, orgID int, org *OrgResponse) error {
+	result := tx.Table(orgsvc.OrgTableName).Select(orgColumns).Where("orgs.id = ?", orgID).Scan(org)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("org_not_found", "org not found")
+	}
+	return nil
+}
+
+func membershipsQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Table(orgsvc.UserOrgRoleTableName).
+		Select("user_org_roles.org_id, orgs.name AS org_name, orgs.slug AS org_slug, user_org_roles.role_id, " +
+			"COALESCE(roles.name, '') AS role_name, user_org_roles.status, user_org_roles.last_accessed_at").
+		Joins("JOIN orgs ON orgs.id = user_org_roles.org_id").
+		Joins("LEFT JOIN roles ON roles.id = user_org_roles.role_id")
+}
+
+// deleteInvitations discards the user's open invitations to the org, so they
+// cannot undo a repaired membership.
+func deleteInvitations(tx *gorm.DB, userID, orgID int) error {
+	return tx.Where("purpose = ? AND user_id = ? AND org_id = ? AND consumed_at IS NULL", tokens.PurposeInvitation, userID, orgID).
+		Delete(&tokens.Token{}).Error
+}
+
+// likePattern matches s anywhere, taking its % and _ literally.
+func likePattern(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
+}
+
+func pageSize(limit int) int {
+	if limit == 0 {
+		return defaultSearchLimit
+	}
+	return limit
+}
@@ -0,0 +1,274 @@
+package admin
+
+import (
+	"log/slog"
+	"org-service/helper"
+	"org-service/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminHTTPTransport interface {
+	SearchUsers(c *fiber.Ctx) error
+	GetUser(c *fiber.Ctx) error
+	SuspendUser(c *fiber.Ctx) error
+	UnsuspendUser(c *fiber.Ctx) error
+	VerifyUserEmail(c *fiber.Ctx) error
+	ImpersonateUser(c *fiber.Ctx) error
+	RepairMembership(c *fiber.Ctx) error
+	RemoveMembership(c *fiber.Ctx) error
+	SearchOrgs(c *fiber.Ctx) error
+	GetOrg(c *fiber.Ctx) error
+	SuspendOrg(c *fiber.Ctx) error
+	UnsuspendOrg(c *fiber.Ctx) error
+}
+
+type adminHTTPTransport struct {
+	adminApi AdminAPI
+	logger   *slog.Logger
+}
+
+func NewAdminHTTPTransport(adminApi AdminAPI, logger *slog.Logger) AdminHTTPTransport {
+	return &adminHTTPTransport{adminApi: adminApi, logger: logger}
+}
+
+func (s *adminHTTPTransport) SearchUsers(c *fiber.Ctx) error {
+	req, err := searchRequest(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.SearchUsers(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) GetUser(c *fiber.Ctx) error {
+	req, err := targetRequest(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.GetUser(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) SuspendUser(c *fiber.Ctx) error {
+	req, err := suspendRequest(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.SuspendUser(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) UnsuspendUser(c *fiber.Ctx) error {
+	req, err := targetRequest(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.UnsuspendUser(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) VerifyUserEmail(c *fiber.Ctx) error {
+	req, err := targetRequest(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.VerifyUserEmail(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) ImpersonateUser(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return helper.FieldInvalid("userId", "must be a number")
+	}
+	req := &ImpersonateRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.ID = userId
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+
+	resp, err := s.adminApi.ImpersonateUser(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) RepairMembership(c *fiber.Ctx) error {
+	userId, orgId, err := membershipParams(c)
+	if err != nil {
+		return err
+	}
+
+	req := &RepairMembershipRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.UserID = userId
+	req.OrgID = orgId
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+
+	resp, err := s.adminApi.RepairMembership(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) RemoveMembership(c *fiber.Ctx) error {
+	userId, orgId, err := membershipParams(c)
+	if err != nil {
+		return err
+	}
+
+	req := &MembershipRequest{}
+	req.UserID = userId
+	req.OrgID = orgId
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+
+	resp, err := s.adminApi.RemoveMembership(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) SearchOrgs(c *fiber.Ctx) error {
+	req, err := searchRequest(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.SearchOrgs(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) GetOrg(c *fiber.Ctx) error {
+	req, err := targetRequest(c, "orgId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.GetOrg(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) SuspendOrg(c *fiber.Ctx) error {
+	req, err := suspendRequest(c, "orgId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.SuspendOrg(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *adminHTTPTransport) UnsuspendOrg(c *fiber.Ctx) error {
+	req, err := targetRequest(c, "orgId")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.adminApi.UnsuspendOrg(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// Private helper funcs
+
+func searchRequest(c *fiber.Ctx) (*SearchRequest, error) {
+	req := &SearchRequest{Query: c.Query("q")}
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if req.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, helper.FieldInvalid("limit", "must be a number")
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if req.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, helper.FieldInvalid("offset", "must be a number")
+		}
+	}
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	return req, nil
+}
+
+func targetRequest(c *fiber.Ctx, param string) (*TargetRequest, error) {
+	id, err := strconv.Atoi(c.Params(param))
+	if err != nil {
+		return nil, helper.FieldInvalid(param, "must be a number")
+	}
+	req := &TargetRequest{ID: id}
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	return req, nil
+}
+
+func suspendRequest(c *fiber.Ctx, param string) (*SuspendRequest, error) {
+	id, err := strconv.Atoi(c.Params(param))
+	if err != nil {
+		return nil, helper.FieldInvalid(param, "must be a number")
+	}
+	req := &SuspendRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return nil, helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.ID = id
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	return req, nil
+}
+
+func membershipParams(c *fiber.Ctx) (userId, orgId int, err error) {
+	if userId, err = strconv.Atoi(c.Params("userId")); err != nil {
+		return 0, 0, helper.FieldInvalid("userId", "must be a number")
+	}
+	if orgId, err = strconv.Atoi(c.Params("orgId")); err != nil {
+		return 0, 0, helper.FieldInvalid("orgId", "must be a number")
+	}
+	return userId, orgId, nil
+}
@@ -0,0 +1,52 @@
+package apikeys
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,unique"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+	OrgID         int      `json:"-" validate:"required"`
+	CurrentUserID int      `json:"-"`
+	CurrentRoleID int      `json:"-"`
+}
+
+type OrgRequest struct {
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+type RevokeAPIKeyRequest struct {
+	KeyID         int `json:"-" validate:"required"`
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"createdBy"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAPIKeyResponse carries the full key. It is returned only once, at
+// creation; afterwards only the prefix is known.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"apiKeys"`
+}
+
+type StatusResponse struct {
+	Status bool `json:"status"`
+}
@@ -0,0 +1,14 @@
+package apikeys
+
+import (
+	"org-service/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterRoutes(orgRouter fiber.Router, apiKeyHttpTransport APIKeyHTTPTransport, rbac middleware.RBAC) {
+	apiKeyRouter := orgRouter.Group("/api-keys")
+	apiKeyRouter.Post("/", middleware.NotWhileImpersonating, rbac.RequireMFA, apiKeyHttpTransport.CreateAPIKey)
+	apiKeyRouter.Get("/", apiKeyHttpTransport.ListAPIKeys)
+	apiKeyRouter.Delete("/:keyId", rbac.RequireMFA, apiKeyHttpTransport.RevokeAPIKey)
+}
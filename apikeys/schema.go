@@ -0,0 +1,25 @@
+package apikeys
+
+import "time"
+
+const (
+	APIKeyTableName = "api_keys"
+)
+
+type APIKey struct {
+	ID         int      `gorm:"primaryKey"`
+	OrgID      int      `gorm:"not null"`
+	Name       string   `gorm:"not null"`
+	Prefix     string   `gorm:"not null"`
+	KeyHash    string   `gorm:"unique;not null"`
+	Scopes     []string `gorm:"serializer:json;type:jsonb"`
+	CreatedBy  *int
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (APIKey) TableName() string {
+	return APIKeyTableName
+}
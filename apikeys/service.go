@@ -0,0 +1,232 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"org-service/helper"
+	"org-service/middleware"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Keys look like osk_<8 hex>_<43 base64url>. The first part is the prefix
+// shown in listings; the hash of the whole key is what is stored.
+const keyPrefix = "osk_"
+
+// last_used_at is written at most this often per key.
+const lastUsedResolution = time.Minute
+
+type apiKeyApi struct {
+	db       *gorm.DB
+	logger   *slog.Logger
+	validate *helper.Validator
+}
+
+type APIKeyAPI interface {
+	CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, req *OrgRequest) (*ListAPIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, req *RevokeAPIKeyRequest) (*StatusResponse, error)
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*middleware.APIKeyPrincipal, error)
+}
+
+func NewAPIKeyService(db *gorm.DB, logger *slog.Logger) APIKeyAPI {
+	return &apiKeyApi{db: db, logger: logger, validate: helper.NewValidator(db)}
+}
+
+// @Summary      	CreateAPIKey
+// @Description		Creates an org-scoped API key for service accounts. The full key is only returned in this response; send it as "Authorization: ApiKey <key>". Owners and admins only.
+// @Tags			APIKeys
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int						true	"OrgID"
+// @Param			CreateAPIKeyRequest				body		CreateAPIKeyRequest		true	"CreateAPIKeyRequest"
+// @Success			201								{object}	CreateAPIKeyResponse
+// @Router			/api/o/{orgId}/api-keys			[POST]
+func (s *apiKeyApi) CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := checkCanManageKeys(req.CurrentUserID, req.CurrentRoleID); err != nil {
+		return nil, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(middleware.APIKeyScopes, scope) {
+			return nil, helper.FieldInvalid("scopes", "must only contain "+strings.Join(middleware.APIKeyScopes, ", "))
+		}
+	}
+
+	rawKey, prefix, err := generateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	createdBy := req.CurrentUserID
+	key := APIKey{
+		OrgID:     req.OrgID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hashKey(rawKey),
+		Scopes:    req.Scopes,
+		CreatedBy: &createdBy,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := db.Create(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	s.logger.Info("api key created", "org_id", req.OrgID, "api_key_id", key.ID, "created_by", req.CurrentUserID)
+
+	return &CreateAPIKeyResponse{APIKeyResponse: toResponse(key), Key: rawKey}, nil
+}
+
+// @Summary      	ListAPIKeys
+// @Description		Lists the org's API keys, including revoked and expired ones. Keys are identified by prefix; the secret is never returned again. Owners and admins only.
+// @Tags			APIKeys
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"OrgID"
+// @Success			200								{object}	ListAPIKeysResponse
+// @Router			/api/o/{orgId}/api-keys			[GET]
+func (s *apiKeyApi) ListAPIKeys(ctx context.Context, req *OrgRequest) (*ListAPIKeysResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := checkCanManageKeys(req.CurrentUserID, req.CurrentRoleID); err != nil {
+		return nil, err
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var keys []APIKey
+	if err := db.Where("org_id = ?", req.OrgID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	res := &ListAPIKeysResponse{APIKeys: make([]APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		res.APIKeys = append(res.APIKeys, toResponse(key))
+	}
+	return res, nil
+}
+
+// @Summary      	RevokeAPIKey
+// @Description		Revokes an API key; requests using it fail from then on. Owners and admins only.
+// @Tags			APIKeys
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"OrgID"
+// @Param			keyId							path		int				true	"API key ID"
+// @Success			200								{object}	StatusResponse
+// @Router			/api/o/{orgId}/api-keys/{keyId}	[DELETE]
+func (s *apiKeyApi) RevokeAPIKey(ctx context.Context, req *RevokeAPIKeyRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := checkCanManageKeys(req.CurrentUserID, req.CurrentRoleID); err != nil {
+		return nil, err
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	result := db.Model(&APIKey{}).
+		Where("id = ? AND org_id = ? AND revoked_at IS NULL", req.KeyID, req.OrgID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, helper.NotFound("api_key_not_found", "api key not found")
+	}
+	s.logger.Info("api key revoked", "org_id", req.OrgID, "api_key_id", req.KeyID, "revoked_by", req.CurrentUserID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// AuthenticateAPIKey implements middleware.APIKeyAuthenticator.
+func (s *apiKeyApi) AuthenticateAPIKey(ctx context.Context, rawKey string) (*middleware.APIKeyPrincipal, error) {
+	db := s.db.WithContext(ctx)
+	if !strings.HasPrefix(rawKey, keyPrefix) {
+		return nil, helper.Unauthorized("invalid_api_key", "Invalid API key")
+	}
+
+	var key APIKey
+	if err := db.Where("key_hash = ? AND revoked_at IS NULL", hashKey(rawKey)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.Unauthorized("invalid_api_key", "Invalid API key")
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, helper.Unauthorized("api_key_expired", "API key has expired")
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		err := db.Model(&APIKey{}).Where("id = ?", key.ID).Update("last_used_at", now).Error
+		if err != nil {
+			s.logger.Warn("failed to record api key use", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	return &middleware.APIKeyPrincipal{ID: key.ID, OrgID: key.OrgID, Scopes: key.Scopes}, nil
+}
+
+// Private helper funcs
+
+// Only owners and admins manage keys, and only as themselves: an API key
+// cannot mint further keys.
+func checkCanManageKeys(currentUserID, currentRoleID int) error {
+	if currentUserID == 0 {
+		return helper.Unauthorized("unauthorized", "api keys can only be managed by users")
+	}
+	if currentRoleID != 1 && currentRoleID != 2 {
+		return helper.Forbidden("permission_denied", "only owners and admins can manage api keys")
+	}
+	return nil
+}
+
+func generateKey() (rawKey, prefix string, err error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefix = keyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// Keys carry 256 random bits, so a fast hash is enough; unlike passwords
+// they cannot be guessed from a dictionary.
+func hashKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func toResponse(key APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -0,0 +1,76 @@
+package apikeys
+
+import (
+	"log/slog"
+	"org-service/helper"
+	"org-service/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyHTTPTransport interface {
+	CreateAPIKey(c *fiber.Ctx) error
+	ListAPIKeys(c *fiber.Ctx) error
+	RevokeAPIKey(c *fiber.Ctx) error
+}
+
+type apiKeyHTTPTransport struct {
+	apiKeyApi APIKeyAPI
+	logger    *slog.Logger
+}
+
+func NewAPIKeyHTTPTransport(apiKeyApi APIKeyAPI, logger *slog.Logger) APIKeyHTTPTransport {
+	return &apiKeyHTTPTransport{apiKeyApi: apiKeyApi, logger: logger}
+}
+
+func (s *apiKeyHTTPTransport) CreateAPIKey(c *fiber.Ctx) error {
+	req := &CreateAPIKeyRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.apiKeyApi.CreateAPIKey(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (s *apiKeyHTTPTransport) ListAPIKeys(c *fiber.Ctx) error {
+	req := &OrgRequest{}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.apiKeyApi.ListAPIKeys(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *apiKeyHTTPTransport) RevokeAPIKey(c *fiber.Ctx) error {
+	keyId, err := strconv.Atoi(c.Params("keyId"))
+	if err != nil {
+		return helper.FieldInvalid("keyId", "must be a number")
+	}
+
+	req := &RevokeAPIKeyRequest{}
+	req.KeyID = keyId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.apiKeyApi.RevokeAPIKey(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const TableName = "audit_events"
+
+// Event records who changed what. Actor is the user or API key that made the
+// change; Details holds what changed, e.g. old and new values by field.
+type Event struct {
+	ID            int `gorm:"primaryKey"`
+	OrgID         *int
+	ActorUserID   *int
+	ActorAPIKeyID *int
+	Action        string                 `gorm:"not null"`
+	TargetType    string                 `gorm:"not null"`
+	TargetID      string                 `gorm:"not null"`
+	Details       map[string]interface{} `gorm:"serializer:json;type:jsonb"`
+	CreatedAt     time.Time
+}
+
+func (Event) TableName() string {
+	return TableName
+}
+
+// Record stores e. Call it with the transaction that makes the change, so the
+// event is only kept if the change is.
+func Record(tx *gorm.DB, e *Event) error {
+	return tx.Create(e).Error
+}
+
+// Change is the Details entry for one changed field.
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
@@ -0,0 +1,151 @@
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Decoders for the accepted upload formats.
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"org-service/helper"
+	"org-service/storage"
+)
+
+// Sizes are the square thumbnails, in pixels, made of every avatar.
+var Sizes = []int{512, 256, 64}
+
+// Upload limits on the width and height. Images are checked before they are
+// decoded, so a small file that would decode to a huge bitmap is rejected
+// cheaply. MaxSide bounds the decoded bitmap to 64 MB of RGBA.
+const (
+	MinSide = 64
+	MaxSide = 4096
+)
+
+// decodeSlots bounds how many uploads are decoded at once, and with it the
+// memory avatars can take however many clients upload together.
+var decodeSlots = make(chan struct{}, 4)
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Process checks that data is an image of an accepted type and size and
+// returns PNG thumbnails keyed by size. The image is cropped to a centered
+// square first. It gives up with ctx's error if ctx ends while waiting for
+// a decode slot.
+func Process(ctx context.Context, data []byte) (map[int][]byte, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, helper.FieldInvalid("avatar", "must be a JPEG, PNG, GIF or WebP image")
+	}
+
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, helper.FieldInvalid("avatar", "is not a readable image")
+	}
+	if imgCfg.Width < MinSide || imgCfg.Height < MinSide || imgCfg.Width > MaxSide || imgCfg.Height > MaxSide {
+		return nil, helper.FieldInvalid("avatar", fmt.Sprintf("must be between %d and %d pixels wide and high", MinSide, MaxSide))
+	}
+
+	select {
+	case decodeSlots <- struct{}{}:
+		defer func() { <-decodeSlots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, helper.FieldInvalid("avatar", "is not a readable image")
+	}
+
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	thumbnails := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		// Never upscale small images; the smaller thumbnails just repeat them.
+		dstSide := min(size, side)
+		dst := image.NewRGBA(image.Rect(0, 0, dstSide, dstSide))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
+
+// NewKey returns a fresh key prefix for an avatar of userID. Each upload gets
+// its own prefix so cached URLs of the old avatar never show the new one.
+func NewKey(userID int) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("avatars/%d/%s", userID, hex.EncodeToString(buf)), nil
+}
+
+// ThumbnailKey is where the thumbnail of the given size of the avatar at key
+// is stored.
+func ThumbnailKey(key string, size int) string {
+	return key + "/" + strconv.Itoa(size) + ".png"
+}
+
+// Save stores the thumbnails under key.
+func Save(ctx context.Context, store storage.BlobStore, key string, thumbnails map[int][]byte) error {
+	for size, data := range thumbnails {
+		if err := store.Put(ctx, ThumbnailKey(key, size), data, "image/png"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes every thumbnail of the avatar at key.
+func Delete(ctx context.Context, store storage.BlobStore, key string) error {
+	for _, size := range Sizes {
+		if err := store.Delete(ctx, ThumbnailKey(key, size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URLs returns signed URLs of the thumbnails of the avatar at key, keyed by
+// size, or nil if the user has no avatar.
+func URLs(ctx context.Context, store storage.BlobStore, key string, ttl time.Duration) (map[int]string, error) {
+	if key == "" {
+		return nil, nil
+	}
+	urls := make(map[int]string, len(Sizes))
+	for _, size := range Sizes {
+		u, err := store.SignedURL(ctx, ThumbnailKey(key, size), ttl)
+		if err != nil {
+			return nil, err
+		}
+		urls[size] = u
+	}
+	return urls, nil
+}
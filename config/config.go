@@ -0,0 +1,814 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+
+	"org-service/logging"
+)
+
+const (
+	EnvProduction = "production"
+	EnvTest       = "test"
+)
+
+// Config is loaded once at startup by Load and passed to the constructors
+// that need it. Values come from, in increasing priority: defaults, the YAML
+// file named by CONFIG_FILE (or ./config.yaml), .env (outside production)
+// and the process environment.
+type Config struct {
+	Env  string `yaml:"env"`
+	Port int    `yaml:"port"`
+	// MetricsPort serves /metrics apart from the public API, so it can be
+	// kept off the load balancer. 0 disables it.
+	MetricsPort     int           `yaml:"metricsPort"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// ShutdownDrainDelay is how long readiness fails before the listener
+	// closes, so load balancers notice and stop sending requests.
+	ShutdownDrainDelay time.Duration       `yaml:"shutdownDrainDelay"`
+	UIAppURL           string              `yaml:"uiAppUrl"`
+	Log                LogConfig           `yaml:"log"`
+	CORS               CORSConfig          `yaml:"cors"`
+	Proxy              ProxyConfig         `yaml:"proxy"`
+	DB                 DBConfig            `yaml:"db"`
+	JWT                JWTConfig           `yaml:"jwt"`
+	Mail               MailConfig          `yaml:"mail"`
+	Tokens             TokenConfig         `yaml:"tokens"`
+	Swagger            SwaggerConfig       `yaml:"swagger"`
+	Tracing            TracingConfig       `yaml:"tracing"`
+	RateLimit          RateLimitConfig     `yaml:"rateLimit"`
+	Storage            StorageConfig       `yaml:"storage"`
+	Teams              TeamsConfig         `yaml:"teams"`
+	Impersonation      ImpersonationConfig `yaml:"impersonation"`
+	MFA                MFAConfig           `yaml:"mfa"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allowOrigins"`
+}
+
+// ProxyConfig is for running behind load balancers. The client IP, which
+// per-IP rate limits and lockouts key on, is read from Header only on
+// requests coming from one of TrustedProxies (IPs or CIDRs); otherwise it is
+// the peer address. Use a header the proxy overwrites, such as X-Real-IP:
+// the first X-Forwarded-For entry is whatever the client sent.
+type ProxyConfig struct {
+	Header         string   `yaml:"header"`
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+type DBConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslMode"`
+	TimeZone        string        `yaml:"timeZone"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	LogLevel        string        `yaml:"logLevel"`
+	SlowQuery       time.Duration `yaml:"slowQuery"`
+}
+
+// JWTConfig controls how user access tokens are verified. HMAC tokens are
+// checked against Secret (tokens without a kid) or Keys (by kid); RS256 and
+// EdDSA tokens against the JWKS file or URL. Every token must carry exp and
+// the configured iss and aud.
+type JWTConfig struct {
+	Secret      string            `yaml:"secret"`
+	Keys        map[string]string `yaml:"keys"`
+	Algorithms  []string          `yaml:"algorithms"`
+	Issuer      string            `yaml:"issuer"`
+	Audience    string            `yaml:"audience"`
+	JWKSFile    string            `yaml:"jwksFile"`
+	JWKSURL     string            `yaml:"jwksUrl"`
+	JWKSRefresh time.Duration     `yaml:"jwksRefresh"`
+	Leeway      time.Duration     `yaml:"leeway"`
+}
+
+// JWTAlgorithms are the signing algorithms JWTConfig.Algorithms may list.
+var JWTAlgorithms = []string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "EdDSA"}
+
+type MailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type TokenConfig struct {
+	InvitationTTL        time.Duration `yaml:"invitationTtl"`
+	EmailVerificationTTL time.Duration `yaml:"emailVerificationTtl"`
+}
+
+// SwaggerConfig is the basic auth login of /api/swagger. The development
+// default password is refused in production.
+type SwaggerConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// TracingConfig selects where spans go. Exporter is "none" (default),
+// "stdout" or "otlp"; Endpoint is the OTLP/HTTP collector URL, e.g.
+// http://localhost:4318.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"serviceName"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// StorageConfig selects where uploaded files such as avatars are kept.
+// Backend is "local" (default), files under Local.Dir served by this service,
+// or "s3" for AWS S3 or an S3-compatible server such as MinIO. Clients get
+// signed URLs that stop working after SignedURLTTL.
+type StorageConfig struct {
+	Backend      string             `yaml:"backend"`
+	SignedURLTTL time.Duration      `yaml:"signedUrlTtl"`
+	Local        LocalStorageConfig `yaml:"local"`
+	S3           S3StorageConfig    `yaml:"s3"`
+}
+
+// LocalStorageConfig stores files in Dir. URLs point at BaseURL, the public
+// URL of this service, and are signed with SigningKey; without one a random
+// key is used, so URLs stop working when the service restarts.
+type LocalStorageConfig struct {
+	Dir        string `yaml:"dir"`
+	BaseURL    string `yaml:"baseUrl"`
+	SigningKey string `yaml:"signingKey"`
+}
+
+// S3StorageConfig points at a bucket. Endpoint is a host[:port] without
+// scheme, e.g. s3.amazonaws.com or localhost:9000 for MinIO.
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"accessKeyId"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	UseSSL          bool   `yaml:"useSsl"`
+	PathStyle       bool   `yaml:"pathStyle"`
+}
+
+// TeamsConfig limits how deep teams nest; a top-level team has depth 1.
+type TeamsConfig struct {
+	MaxDepth int `yaml:"maxDepth"`
+}
+
+// ImpersonationConfig lets superadmins act as another user. Tokens are
+// signed with SigningKey, which must differ from the JWT keys, and expire
+// after TTL. Without a SigningKey impersonation is disabled.
+type ImpersonationConfig struct {
+	SigningKey string        `yaml:"signingKey"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// MFAConfig controls TOTP multi-factor authentication. Issuer is the account
+// label authenticator apps show. TOTP secrets are encrypted with SecretKey;
+// without one they are stored in plain text, which is refused in production.
+type MFAConfig struct {
+	Issuer    string `yaml:"issuer"`
+	SecretKey string `yaml:"secretKey"`
+}
+
+// RateLimitConfig holds the per-route request limits, keyed by the route
+// names the routers pass to middleware.RateLimiter, and the lockout applied
+// after repeated failed credential attempts. Store is "memory" (default),
+// counting per replica, or "redis", shared by every replica using Redis.
+type RateLimitConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Store   string                 `yaml:"store"`
+	Redis   RedisConfig            `yaml:"redis"`
+	Routes  map[string]RouteLimits `yaml:"routes"`
+	Lockout LockoutConfig          `yaml:"lockout"`
+}
+
+// RedisConfig is a Redis-compatible server (Redis, Valkey, KeyDB, ...).
+// Keys are stored under Prefix.
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	Prefix   string `yaml:"prefix"`
+}
+
+// RouteLimits has one bucket per client IP, authenticated user and org. A
+// zero Limit disables that bucket.
+type RouteLimits struct {
+	PerIP   Rate `yaml:"perIp"`
+	PerUser Rate `yaml:"perUser"`
+	PerOrg  Rate `yaml:"perOrg"`
+}
+
+type Rate struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+}
+
+// LockoutConfig blocks a client for Duration once it has failed MaxFailures
+// times within Window.
+type LockoutConfig struct {
+	MaxFailures int           `yaml:"maxFailures"`
+	Window      time.Duration `yaml:"window"`
+	Duration    time.Duration `yaml:"duration"`
+}
+
+// defaultRouteLimits apply to routes the YAML file does not configure. They
+// are merged after loading because strict YAML decoding rejects keys that are
+// already present in a map.
+var defaultRouteLimits = map[string]RouteLimits{
+	"invite": {
+		PerIP:   Rate{Limit: 60, Window: time.Hour},
+		PerUser: Rate{Limit: 20, Window: time.Hour},
+		PerOrg:  Rate{Limit: 100, Window: time.Hour},
+	},
+	"accept_invitation": {
+		PerIP: Rate{Limit: 10, Window: time.Minute},
+	},
+	"verify_email": {
+		PerIP: Rate{Limit: 10, Window: time.Minute},
+	},
+	"resend_verification": {
+		PerIP:   Rate{Limit: 10, Window: time.Hour},
+		PerUser: Rate{Limit: 3, Window: time.Hour},
+	},
+	"change_password": {
+		PerIP:   Rate{Limit: 20, Window: 15 * time.Minute},
+		PerUser: Rate{Limit: 5, Window: 15 * time.Minute},
+	},
+	"change_email": {
+		PerIP:   Rate{Limit: 10, Window: time.Hour},
+		PerUser: Rate{Limit: 5, Window: time.Hour},
+	},
+	"upload_avatar": {
+		PerUser: Rate{Limit: 20, Window: time.Hour},
+	},
+	"mfa_verify": {
+		PerIP:   Rate{Limit: 20, Window: 15 * time.Minute},
+		PerUser: Rate{Limit: 5, Window: 15 * time.Minute},
+	},
+}
+
+const defaultSwaggerPassword = "123123123"
+
+func defaults() *Config {
+	return &Config{
+		Port:               3002,
+		MetricsPort:        9090,
+		ShutdownTimeout:    15 * time.Second,
+		ShutdownDrainDelay: 5 * time.Second,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+		},
+		DB: DBConfig{
+			Port:            5432,
+			SSLMode:         "require",
+			TimeZone:        "UTC",
+			MaxIdleConns:    10,
+			MaxOpenConns:    100,
+			ConnMaxLifetime: time.Hour,
+			LogLevel:        "warn",
+			SlowQuery:       200 * time.Millisecond,
+		},
+		Mail: MailConfig{
+			Host: "smtp.gmail.com",
+			Port: 587,
+		},
+		Tokens: TokenConfig{
+			InvitationTTL:        24 * time.Hour,
+			EmailVerificationTTL: 48 * time.Hour,
+		},
+		Swagger: SwaggerConfig{
+			Username: "influxo",
+			Password: defaultSwaggerPassword,
+		},
+		JWT: JWTConfig{
+			Algorithms:  []string{"HS256"},
+			JWKSRefresh: 15 * time.Minute,
+			Leeway:      30 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "org-service",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Redis: RedisConfig{
+				Prefix: "org-service:ratelimit:",
+			},
+			Lockout: LockoutConfig{
+				MaxFailures: 5,
+				Window:      15 * time.Minute,
+				Duration:    15 * time.Minute,
+			},
+		},
+		Teams: TeamsConfig{
+			MaxDepth: 3,
+		},
+		Impersonation: ImpersonationConfig{
+			TTL: 30 * time.Minute,
+		},
+		MFA: MFAConfig{
+			Issuer: "org-service",
+		},
+		Storage: StorageConfig{
+			Backend:      "local",
+			SignedURLTTL: time.Hour,
+			Local: LocalStorageConfig{
+				Dir:     "./data/blobs",
+				BaseURL: "http://localhost:3002",
+			},
+			S3: S3StorageConfig{
+				Region: "us-east-1",
+				UseSSL: true,
+			},
+		},
+	}
+}
+
+// Load builds the Config and validates it.
+func Load() (*Config, error) {
+	cfg := defaults()
+
+	if err := loadYAML(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Routes == nil {
+		cfg.RateLimit.Routes = make(map[string]RouteLimits)
+	}
+	for route, limits := range defaultRouteLimits {
+		if _, ok := cfg.RateLimit.Routes[route]; !ok {
+			cfg.RateLimit.Routes[route] = limits
+		}
+	}
+
+	// Load .env file only in development
+	if os.Getenv("ENV") != EnvProduction {
+		if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("loading .env: %w", err)
+		}
+	}
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadYAML(cfg *Config) error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		path = "config.yaml"
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	var errs []error
+
+	setString(&cfg.Env, "ENV")
+	errs = append(errs,
+		setInt(&cfg.Port, "PORT"),
+		setInt(&cfg.MetricsPort, "METRICS_PORT"),
+		setDuration(&cfg.ShutdownTimeout, "SHUTDOWN_TIMEOUT"),
+		setDuration(&cfg.ShutdownDrainDelay, "SHUTDOWN_DRAIN_DELAY"),
+	)
+	setString(&cfg.UIAppURL, "UI_APP_URL")
+	setString(&cfg.Log.Level, "LOG_LEVEL")
+	setString(&cfg.Log.Format, "LOG_FORMAT")
+	if origins := os.Getenv("CORS_ALLOW_ORIGINS"); origins != "" {
+		cfg.CORS.AllowOrigins = splitList(origins)
+	}
+	setString(&cfg.Proxy.Header, "PROXY_HEADER")
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		cfg.Proxy.TrustedProxies = splitList(proxies)
+	}
+
+	// ENV=test points at the separate TEST_DB_* database.
+	dbPrefix := "DB_"
+	if cfg.Env == EnvTest {
+		dbPrefix = "TEST_DB_"
+		cfg.DB.SSLMode = "disable"
+	}
+	setString(&cfg.DB.Host, dbPrefix+"HOST")
+	errs = append(errs, setInt(&cfg.DB.Port, dbPrefix+"PORT"))
+	setString(&cfg.DB.User, dbPrefix+"USERNAME")
+	setString(&cfg.DB.Password, dbPrefix+"PASSWORD")
+	setString(&cfg.DB.Name, dbPrefix+"NAME")
+	setString(&cfg.DB.SSLMode, "DB_SSLMODE")
+	setString(&cfg.DB.TimeZone, "DB_TIMEZONE")
+	errs = append(errs,
+		setInt(&cfg.DB.MaxIdleConns, "DB_MAX_IDLE_CONNS"),
+		setInt(&cfg.DB.MaxOpenConns, "DB_MAX_OPEN_CONNS"),
+		setDuration(&cfg.DB.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME"),
+		setDuration(&cfg.DB.SlowQuery, "DB_SLOW_QUERY"),
+	)
+	setString(&cfg.DB.LogLevel, "DB_LOG_LEVEL")
+
+	// JWT_SECRET is the old name, from when it also signed invitations.
+	setString(&cfg.JWT.Secret, "JWT_SECRET")
+	setString(&cfg.JWT.Secret, "JWT_SECRET_KEY")
+	if keys := os.Getenv("JWT_KEYS"); keys != "" {
+		// kid1:secret1,kid2:secret2
+		cfg.JWT.Keys = make(map[string]string)
+		for _, pair := range splitList(keys) {
+			kid, secret, ok := strings.Cut(pair, ":")
+			if !ok || kid == "" || secret == "" {
+				errs = append(errs, fmt.Errorf("JWT_KEYS: entries must look like kid:secret"))
+				continue
+			}
+			cfg.JWT.Keys[kid] = secret
+		}
+	}
+	if algs := os.Getenv("JWT_ALGORITHMS"); algs != "" {
+		cfg.JWT.Algorithms = splitList(algs)
+	}
+	setString(&cfg.JWT.Issuer, "JWT_ISSUER")
+	setString(&cfg.JWT.Audience, "JWT_AUDIENCE")
+	setString(&cfg.JWT.JWKSFile, "JWT_JWKS_FILE")
+	setString(&cfg.JWT.JWKSURL, "JWT_JWKS_URL")
+	errs = append(errs,
+		setDuration(&cfg.JWT.JWKSRefresh, "JWT_JWKS_REFRESH"),
+		setDuration(&cfg.JWT.Leeway, "JWT_LEEWAY"),
+	)
+
+	setString(&cfg.Mail.Host, "MAIL_HOST")
+	errs = append(errs, setInt(&cfg.Mail.Port, "MAIL_PORT"))
+	setString(&cfg.Mail.Username, "EMAIL_FROM")
+	setString(&cfg.Mail.Password, "MAIL_PASSWORD")
+	setString(&cfg.Mail.From, "MAIL_FROM")
+	if cfg.Mail.From == "" {
+		cfg.Mail.From = cfg.Mail.Username
+	}
+
+	errs = append(errs, setDuration(&cfg.Tokens.InvitationTTL, "INVITATION_TOKEN_TTL"))
+	errs = append(errs, setDuration(&cfg.Tokens.EmailVerificationTTL, "EMAIL_VERIFICATION_TOKEN_TTL"))
+
+	setString(&cfg.Swagger.Username, "SWAGGER_USERNAME")
+	setString(&cfg.Swagger.Password, "SWAGGER_PASSWORD")
+
+	// Standard OpenTelemetry variable names, so collector setups can be reused.
+	setString(&cfg.Tracing.Exporter, "OTEL_TRACES_EXPORTER")
+	setString(&cfg.Tracing.Endpoint, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	setString(&cfg.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	errs = append(errs, setFloat(&cfg.Tracing.SampleRatio, "OTEL_TRACES_SAMPLER_ARG"))
+
+	// Per-route limits are only configurable in the YAML file.
+	setString(&cfg.RateLimit.Store, "RATE_LIMIT_STORE")
+	setString(&cfg.RateLimit.Redis.Addr, "REDIS_ADDR")
+	setString(&cfg.RateLimit.Redis.Password, "REDIS_PASSWORD")
+	setString(&cfg.RateLimit.Redis.Prefix, "REDIS_PREFIX")
+	errs = append(errs,
+		setBool(&cfg.RateLimit.Enabled, "RATE_LIMIT_ENABLED"),
+		setInt(&cfg.RateLimit.Redis.DB, "REDIS_DB"),
+		setInt(&cfg.RateLimit.Lockout.MaxFailures, "LOCKOUT_MAX_FAILURES"),
+		setDuration(&cfg.RateLimit.Lockout.Window, "LOCKOUT_WINDOW"),
+		setDuration(&cfg.RateLimit.Lockout.Duration, "LOCKOUT_DURATION"),
+	)
+
+	errs = append(errs, setInt(&cfg.Teams.MaxDepth, "TEAMS_MAX_DEPTH"))
+
+	setString(&cfg.Impersonation.SigningKey, "IMPERSONATION_SIGNING_KEY")
+	errs = append(errs, setDuration(&cfg.Impersonation.TTL, "IMPERSONATION_TOKEN_TTL"))
+
+	setString(&cfg.MFA.Issuer, "MFA_ISSUER")
+	setString(&cfg.MFA.SecretKey, "MFA_SECRET_KEY")
+
+	setString(&cfg.Storage.Backend, "STORAGE_BACKEND")
+	errs = append(errs, setDuration(&cfg.Storage.SignedURLTTL, "STORAGE_SIGNED_URL_TTL"))
+	setString(&cfg.Storage.Local.Dir, "STORAGE_LOCAL_DIR")
+	setString(&cfg.Storage.Local.BaseURL, "STORAGE_LOCAL_BASE_URL")
+	setString(&cfg.Storage.Local.SigningKey, "STORAGE_LOCAL_SIGNING_KEY")
+	setString(&cfg.Storage.S3.Endpoint, "S3_ENDPOINT")
+	setString(&cfg.Storage.S3.Region, "S3_REGION")
+	setString(&cfg.Storage.S3.Bucket, "S3_BUCKET")
+	setString(&cfg.Storage.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+	setString(&cfg.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	errs = append(errs,
+		setBool(&cfg.Storage.S3.UseSSL, "S3_USE_SSL"),
+		setBool(&cfg.Storage.S3.PathStyle, "S3_PATH_STYLE"),
+	)
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every missing or invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Port <= 0 || c.Port > 65535 {
+		problems = append(problems, "PORT must be between 1 and 65535")
+	}
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		problems = append(problems, "METRICS_PORT must be between 0 and 65535")
+	} else if c.MetricsPort == c.Port {
+		problems = append(problems, "METRICS_PORT must differ from PORT")
+	}
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.ShutdownDrainDelay < 0 {
+		problems = append(problems, "SHUTDOWN_DRAIN_DELAY must not be negative")
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, "LOG_LEVEL must be one of debug, info, warn, error")
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problems = append(problems, "LOG_FORMAT must be json or text")
+	}
+	if _, err := logging.ParseGormLevel(c.DB.LogLevel); err != nil {
+		problems = append(problems, "DB_LOG_LEVEL must be one of silent, error, warn, info")
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		problems = append(problems, "CORS_ALLOW_ORIGINS must list at least one origin")
+	}
+	if c.Proxy.Header != "" && len(c.Proxy.TrustedProxies) == 0 {
+		problems = append(problems, "PROXY_HEADER needs TRUSTED_PROXIES, or any client could set its own IP")
+	}
+	for _, proxy := range c.Proxy.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES: %q is not an IP or CIDR", proxy))
+		}
+	}
+	problems = append(problems, c.JWT.validate()...)
+	if c.DB.Host == "" {
+		problems = append(problems, "DB_HOST is required")
+	}
+	if c.DB.User == "" {
+		problems = append(problems, "DB_USERNAME is required")
+	}
+	if c.DB.Name == "" {
+		problems = append(problems, "DB_NAME is required")
+	}
+	if c.DB.MaxOpenConns < 1 {
+		problems = append(problems, "DB_MAX_OPEN_CONNS must be at least 1")
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		problems = append(problems, "DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	}
+	if c.Mail.Host == "" || c.Mail.Port <= 0 {
+		problems = append(problems, "MAIL_HOST and MAIL_PORT are required")
+	}
+	if c.Mail.From == "" {
+		problems = append(problems, "EMAIL_FROM or MAIL_FROM is required")
+	}
+	if c.Tokens.InvitationTTL <= 0 {
+		problems = append(problems, "INVITATION_TOKEN_TTL must be positive")
+	}
+	if c.Tokens.EmailVerificationTTL <= 0 {
+		problems = append(problems, "EMAIL_VERIFICATION_TOKEN_TTL must be positive")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			problems = append(problems, "OTEL_EXPORTER_OTLP_ENDPOINT is required for the otlp exporter")
+		}
+	default:
+		problems = append(problems, "OTEL_TRACES_EXPORTER must be none, stdout or otlp")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+
+	for route, limits := range c.RateLimit.Routes {
+		for bucket, rate := range map[string]Rate{"perIp": limits.PerIP, "perUser": limits.PerUser, "perOrg": limits.PerOrg} {
+			if rate.Limit < 0 || (rate.Limit > 0 && rate.Window <= 0) {
+				problems = append(problems, fmt.Sprintf("rateLimit.routes.%s.%s needs a non-negative limit and a positive window", route, bucket))
+			}
+		}
+	}
+	if c.RateLimit.Lockout.MaxFailures < 1 || c.RateLimit.Lockout.Window <= 0 || c.RateLimit.Lockout.Duration <= 0 {
+		problems = append(problems, "LOCKOUT_MAX_FAILURES, LOCKOUT_WINDOW and LOCKOUT_DURATION must be positive")
+	}
+	switch c.RateLimit.Store {
+	case "memory":
+	case "redis":
+		if c.RateLimit.Redis.Addr == "" {
+			problems = append(problems, "REDIS_ADDR is required for the redis rate limit store")
+		}
+	default:
+		problems = append(problems, "RATE_LIMIT_STORE must be memory or redis")
+	}
+
+	if c.Teams.MaxDepth < 1 {
+		problems = append(problems, "TEAMS_MAX_DEPTH must be at least 1")
+	}
+	if c.Impersonation.TTL <= 0 || c.Impersonation.TTL > 4*time.Hour {
+		problems = append(problems, "IMPERSONATION_TOKEN_TTL must be positive and at most 4h")
+	}
+	if key := c.Impersonation.SigningKey; key != "" {
+		if len(key) < 32 {
+			problems = append(problems, "IMPERSONATION_SIGNING_KEY must be at least 32 bytes")
+		}
+		reused := key == c.JWT.Secret
+		for _, secret := range c.JWT.Keys {
+			reused = reused || key == secret
+		}
+		if reused {
+			problems = append(problems, "IMPERSONATION_SIGNING_KEY must differ from the JWT keys")
+		}
+	}
+	if c.MFA.Issuer == "" || strings.Contains(c.MFA.Issuer, ":") {
+		problems = append(problems, "MFA_ISSUER is required and must not contain a colon")
+	}
+	if c.MFA.SecretKey != "" && len(c.MFA.SecretKey) < 32 {
+		problems = append(problems, "MFA_SECRET_KEY must be at least 32 bytes")
+	}
+	if c.Storage.SignedURLTTL <= 0 {
+		problems = append(problems, "STORAGE_SIGNED_URL_TTL must be positive")
+	}
+	switch c.Storage.Backend {
+	case "local":
+		if c.Storage.Local.Dir == "" || c.Storage.Local.BaseURL == "" {
+			problems = append(problems, "STORAGE_LOCAL_DIR and STORAGE_LOCAL_BASE_URL are required for the local storage backend")
+		}
+	case "s3":
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" {
+			problems = append(problems, "S3_ENDPOINT and S3_BUCKET are required for the s3 storage backend")
+		}
+	default:
+		problems = append(problems, "STORAGE_BACKEND must be local or s3")
+	}
+
+	if c.Env == EnvProduction {
+		if c.DB.Password == "" {
+			problems = append(problems, "DB_PASSWORD is required in production")
+		}
+		if c.Mail.Password == "" {
+			problems = append(problems, "MAIL_PASSWORD is required in production")
+		}
+		if c.UIAppURL == "" {
+			problems = append(problems, "UI_APP_URL is required in production")
+		}
+		if c.Storage.Backend == "local" && c.Storage.Local.SigningKey == "" {
+			problems = append(problems, "STORAGE_LOCAL_SIGNING_KEY is required in production")
+		}
+		if c.MFA.SecretKey == "" {
+			problems = append(problems, "MFA_SECRET_KEY is required in production")
+		}
+		if c.Swagger.Password == "" || c.Swagger.Password == defaultSwaggerPassword {
+			problems = append(problems, "SWAGGER_PASSWORD is required in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func (j *JWTConfig) validate() []string {
+	var problems []string
+	if j.Issuer == "" || j.Audience == "" {
+		problems = append(problems, "JWT_ISSUER and JWT_AUDIENCE are required")
+	}
+	if len(j.Algorithms) == 0 {
+		problems = append(problems, "JWT_ALGORITHMS must list at least one algorithm")
+	}
+	var hmac, asymmetric bool
+	for _, alg := range j.Algorithms {
+		switch {
+		case !slices.Contains(JWTAlgorithms, alg):
+			problems = append(problems, fmt.Sprintf("JWT_ALGORITHMS: %q is not one of %s", alg, strings.Join(JWTAlgorithms, ", ")))
+		case strings.HasPrefix(alg, "HS"):
+			hmac = true
+		default:
+			asymmetric = true
+		}
+	}
+	if hmac && j.Secret == "" && len(j.Keys) == 0 {
+		problems = append(problems, "HMAC algorithms need JWT_SECRET_KEY or JWT_KEYS")
+	}
+	if asymmetric && j.JWKSFile == "" && j.JWKSURL == "" {
+		problems = append(problems, "RS256/EdDSA need JWT_JWKS_FILE or JWT_JWKS_URL")
+	}
+	if j.JWKSFile != "" && j.JWKSURL != "" {
+		problems = append(problems, "set only one of JWT_JWKS_FILE and JWT_JWKS_URL")
+	}
+	if j.JWKSURL != "" && j.JWKSRefresh <= 0 {
+		problems = append(problems, "JWT_JWKS_REFRESH must be positive")
+	}
+	if j.Leeway < 0 {
+		problems = append(problems, "JWT_LEEWAY must not be negative")
+	}
+	return problems
+}
+
+// Addr is the listen address for the HTTP server.
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// MetricsAddr is the listen address for /metrics.
+func (c *Config) MetricsAddr() string {
+	return fmt.Sprintf(":%d", c.MetricsPort)
+}
+
+func setString(dst *string, name string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+func setInt(dst *int, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a number", name, v)
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a boolean", name, v)
+	}
+	*dst = b
+	return nil
+}
+
+func setFloat(dst *float64, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a number", name, v)
+	}
+	*dst = f
+	return nil
+}
+
+func setDuration(dst *time.Duration, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a duration (e.g. 30m, 24h)", name, v)
+	}
+	*dst = d
+	return nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig is the defaults plus what has no default.
+func validConfig() *Config {
+	cfg := defaults()
+	cfg.DB.Host = "localhost"
+	cfg.DB.User = "postgres"
+	cfg.DB.Name = "org_service"
+	cfg.Mail.Host = "smtp.example.com"
+	cfg.Mail.Port = 587
+	cfg.Mail.From = "noreply@example.com"
+	cfg.JWT.Issuer = "https://auth.example.com"
+	cfg.JWT.Audience = "org-service"
+	cfg.JWT.Secret = "jwt-secret-jwt-secret-jwt-secret"
+	return cfg
+}
+
+// production is a valid production config.
+func production(cfg *Config) {
+	cfg.Env = EnvProduction
+	cfg.DB.Password = "db-password"
+	cfg.Mail.Password = "mail-password"
+	cfg.UIAppURL = "https://app.example.com"
+	cfg.Storage.Local.SigningKey = "storage-signing-key"
+	cfg.MFA.SecretKey = "mfa-secret-key-mfa-secret-key-mfa-secret-key"
+	cfg.Swagger.Password = "swagger-password"
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		problem string // expected in the error, or "" if valid
+	}{
+		{"defaults", func(*Config) {}, ""},
+		{"production", production, ""},
+		{"port out of range", func(c *Config) { c.Port = 70000 }, "PORT must be between 1 and 65535"},
+		{"metrics port clashes", func(c *Config) { c.MetricsPort = c.Port }, "METRICS_PORT must differ from PORT"},
+		{"metrics disabled", func(c *Config) { c.MetricsPort = 0 }, ""},
+		{"negative drain delay", func(c *Config) { c.ShutdownDrainDelay = -time.Second }, "SHUTDOWN_DRAIN_DELAY"},
+		{"unknown log level", func(c *Config) { c.Log.Level = "verbose" }, "LOG_LEVEL"},
+		{"no cors origins", func(c *Config) { c.CORS.AllowOrigins = nil }, "CORS_ALLOW_ORIGINS"},
+		{"proxy header without proxies", func(c *Config) { c.Proxy.Header = "X-Real-IP" }, "PROXY_HEADER needs TRUSTED_PROXIES"},
+		{"trusted proxies", func(c *Config) {
+			c.Proxy.Header = "X-Real-IP"
+			c.Proxy.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1"}
+		}, ""},
+		{"bad trusted proxy", func(c *Config) { c.Proxy.TrustedProxies = []string{"lb.internal"} }, `"lb.internal" is not an IP or CIDR`},
+		{"missing db host", func(c *Config) { c.DB.Host = "" }, "DB_HOST is required"},
+		{"idle above open conns", func(c *Config) { c.DB.MaxIdleConns = c.DB.MaxOpenConns + 1 }, "DB_MAX_IDLE_CONNS"},
+		{"missing jwt audience", func(c *Config) { c.JWT.Audience = "" }, "JWT_ISSUER and JWT_AUDIENCE are required"},
+		{"unknown jwt algorithm", func(c *Config) { c.JWT.Algorithms = []string{"none"} }, `"none" is not one of`},
+		{"hmac without secret", func(c *Config) { c.JWT.Secret = "" }, "HMAC algorithms need"},
+		{"rsa without keys", func(c *Config) { c.JWT.Algorithms = []string{"RS256"} }, "RS256/EdDSA need"},
+		{"otlp without endpoint", func(c *Config) {
+			c.Tracing.Exporter = "otlp"
+			c.Tracing.Endpoint = ""
+		}, "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		{"sample ratio above 1", func(c *Config) { c.Tracing.SampleRatio = 1.5 }, "OTEL_TRACES_SAMPLER_ARG"},
+		{"rate without window", func(c *Config) {
+			c.RateLimit.Routes = map[string]RouteLimits{"invite": {PerIP: Rate{Limit: 5}}}
+		}, "rateLimit.routes.invite.perIp"},
+		{"impersonation key reuses jwt secret", func(c *Config) { c.Impersonation.SigningKey = c.JWT.Secret }, "must differ from the JWT keys"},
+		{"short impersonation key", func(c *Config) { c.Impersonation.SigningKey = "short" }, "at least 32 bytes"},
+		{"mfa issuer with colon", func(c *Config) { c.MFA.Issuer = "Org:Service" }, "MFA_ISSUER"},
+		{"redis store without addr", func(c *Config) { c.RateLimit.Store = "redis" }, "REDIS_ADDR is required"},
+		{"redis store", func(c *Config) {
+			c.RateLimit.Store = "redis"
+			c.RateLimit.Redis.Addr = "localhost:6379"
+		}, ""},
+		{"unknown rate limit store", func(c *Config) { c.RateLimit.Store = "memcached" }, "RATE_LIMIT_STORE"},
+		{"unknown storage backend", func(c *Config) { c.Storage.Backend = "ftp" }, "STORAGE_BACKEND"},
+		{"production without db password", func(c *Config) {
+			production(c)
+			c.DB.Password = ""
+		}, "DB_PASSWORD is required in production"},
+		{"production with default swagger password", func(c *Config) {
+			production(c)
+			c.Swagger.Password = defaultSwaggerPassword
+		}, "SWAGGER_PASSWORD is required in production"},
+		{"production without mfa key", func(c *Config) {
+			production(c)
+			c.MFA.SecretKey = ""
+		}, "MFA_SECRET_KEY is required in production"},
+		{"default swagger password outside production", func(*Config) {}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Port = 0
+	cfg.DB.Host = ""
+	cfg.Mail.From = ""
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, problem := range []string{"PORT", "DB_HOST", "MAIL_FROM"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error does not mention %s:\n%v", problem, err)
+		}
+	}
+}
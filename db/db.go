@@ -2,58 +2,26 @@ package db
 
 import (
 	"fmt"
-	"os"
-	"strconv"
-	"time"
+	"log/slog"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-)
 
-func getEnvAsInt(name string, defaultVal int) int {
-	valueStr := os.Getenv(name)
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
-	}
-	return defaultVal
-}
+	"org-service/config"
+	"org-service/logging"
+)
 
-func ConnectDB() (*gorm.DB, error) {
-	// Load .env file only in development
-	if os.Getenv("ENV") != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			fmt.Print("Error loading .env file")
-		}
-	}
+func ConnectDB(cfg config.DBConfig, logger *slog.Logger) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode, cfg.TimeZone)
 
-	var dbhost, dbuser, dbpassword, dbname string
-	var dbport int
+	logger.Info("connecting to database", "host", cfg.Host, "port", cfg.Port, "name", cfg.Name)
 
-	// Determine which database configuration to use based on the ENV variable
-	fmt.Println("Env", os.Getenv("ENV"))
-	var dsn string
-	switch os.Getenv("ENV") {
-	case "test":
-		dbhost = os.Getenv("TEST_DB_HOST")
-		dbport = getEnvAsInt("TEST_DB_PORT", 5432)
-		dbuser = os.Getenv("TEST_DB_USERNAME")
-		dbpassword = os.Getenv("TEST_DB_PASSWORD")
-		dbname = os.Getenv("TEST_DB_NAME")
-		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d  TimeZone=Asia/Jakarta", dbhost, dbuser, dbpassword, dbname, dbport)
-	default:
-		dbhost = os.Getenv("DB_HOST")
-		dbport = getEnvAsInt("DB_PORT", 5432)
-		dbuser = os.Getenv("DB_USERNAME")
-		dbpassword = os.Getenv("DB_PASSWORD")
-		dbname = os.Getenv("DB_NAME")
-		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=require TimeZone=Asia/Jakarta", dbhost, dbuser, dbpassword, dbname, dbport)
+	gormLogger, err := logging.NewGormLogger(logger, cfg.LogLevel, cfg.SlowQuery)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Connecting to DB at %s:%d with user %s\n", dbhost, dbport, dbuser)
-
 	// Use pgx as the driver
 
 	// Open the database connection using postgres with pgx
@@ -61,11 +29,10 @@ func ConnectDB() (*gorm.DB, error) {
 		DSN:                  dsn,
 		PreferSimpleProtocol: true, // disables implicit prepared statement usage
 	}), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // Enable detailed logging
+		Logger: gormLogger,
 	})
 	if err != nil {
-		fmt.Printf("Failed to connect to database: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Set connection pool settings
@@ -73,9 +40,9 @@ func ConnectDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db, nil
 }
@@ -0,0 +1,314 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SchemaMigrationsTableName = "schema_migrations"
+
+	// Arbitrary key used with pg_advisory_xact_lock so that two instances
+	// running migrations at the same time apply each version only once.
+	migrationLockKey = 72_617_301
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the embedded migrations/<version>_<name>.(up|down).sql
+// files and returns them sorted by version.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", fileName, err)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join("migrations", fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// LatestMigrationVersion returns the highest embedded migration version.
+func LatestMigrationVersion() (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// MigrateUp applies every pending migration.
+func MigrateUp(db *gorm.DB) error {
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	return MigrateTo(db, latest)
+}
+
+// MigrateDown reverts the last `steps` applied migrations.
+func MigrateDown(db *gorm.DB, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	current, err := CurrentMigrationVersion(db)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	target := 0
+	applied := 0
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version > current {
+			continue
+		}
+		applied++
+		if applied > steps {
+			target = migrations[i].Version
+			break
+		}
+	}
+
+	return MigrateTo(db, target)
+}
+
+// MigrateTo applies or reverts migrations until the schema is at `version`.
+// Version 0 reverts everything.
+func MigrateTo(db *gorm.DB, version int) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if version != 0 {
+		found := false
+		for _, m := range migrations {
+			if m.Version == version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+	}
+
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if err := revertMigration(db, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CurrentMigrationVersion returns the highest applied version, or 0.
+func CurrentMigrationVersion(db *gorm.DB) (int, error) {
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return 0, err
+	}
+
+	var version int
+	result := db.Table(SchemaMigrationsTableName).Select("COALESCE(MAX(version), 0)").Scan(&version)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return version, nil
+}
+
+// GetMigrationStatus lists every embedded migration and when it was applied.
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	if err := db.Table(SchemaMigrationsTableName).Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := map[int]time.Time{}
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if t, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func ensureSchemaMigrationsTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS ` + SchemaMigrationsTableName + ` (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`).Error
+}
+
+func isMigrationApplied(tx *gorm.DB, version int) (bool, error) {
+	var count int64
+	result := tx.Table(SchemaMigrationsTableName).Where("version = ?", version).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+func applyMigration(db *gorm.DB, m Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+
+		applied, err := isMigrationApplied(tx, m.Version)
+		if err != nil || applied {
+			return err
+		}
+
+		if err := tx.Exec(m.Up).Error; err != nil {
+			return fmt.Errorf("migration %d_%s up failed: %w", m.Version, m.Name, err)
+		}
+
+		return tx.Table(SchemaMigrationsTableName).Create(&schemaMigration{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+func revertMigration(db *gorm.DB, m Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+
+		applied, err := isMigrationApplied(tx, m.Version)
+		if err != nil || !applied {
+			return err
+		}
+
+		if m.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+
+		if err := tx.Exec(m.Down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s down failed: %w", m.Version, m.Name, err)
+		}
+
+		return tx.Table(SchemaMigrationsTableName).Where("version = ?", m.Version).Delete(&schemaMigration{}).Error
+	})
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_org_roles;
+DROP TABLE IF EXISTS orgs;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS users;
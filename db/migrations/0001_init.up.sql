@@ -0,0 +1,59 @@
+-- Baseline schema. Tables may already exist on databases that were created
+-- by the old startup AutoMigrate or by communihub, hence IF NOT EXISTS.
+
+CREATE TABLE IF NOT EXISTS users (
+    id             BIGSERIAL PRIMARY KEY,
+    email          TEXT NOT NULL,
+    username       TEXT,
+    org_id         BIGINT,
+    password       TEXT NOT NULL DEFAULT '',
+    first_name     TEXT NOT NULL DEFAULT '',
+    last_name      TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL DEFAULT '',
+    avatar_img_key TEXT NOT NULL DEFAULT '',
+    active         BOOLEAN NOT NULL DEFAULT FALSE,
+    phone          TEXT NOT NULL DEFAULT '',
+    verified_email BOOLEAN NOT NULL DEFAULT FALSE,
+    role           TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id          BIGSERIAL PRIMARY KEY,
+    http_method TEXT NOT NULL,
+    path        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       BIGINT NOT NULL,
+    permission_id BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orgs (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    size       TEXT NOT NULL,
+    slug       TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS user_org_roles (
+    user_id BIGINT,
+    org_id  BIGINT,
+    role_id BIGINT,
+    status  TEXT
+);
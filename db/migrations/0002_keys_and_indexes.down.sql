@@ -0,0 +1,27 @@
+DROP INDEX IF EXISTS idx_permissions_method_path;
+
+ALTER TABLE role_permissions
+    DROP CONSTRAINT IF EXISTS role_permissions_permission_id_fkey,
+    DROP CONSTRAINT IF EXISTS role_permissions_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS role_permissions_pkey;
+
+DROP INDEX IF EXISTS idx_roles_name;
+DROP INDEX IF EXISTS idx_orgs_slug;
+DROP INDEX IF EXISTS idx_users_username;
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_orgs_deleted_at;
+DROP INDEX IF EXISTS idx_user_org_roles_role_id;
+DROP INDEX IF EXISTS idx_user_org_roles_org_id;
+
+ALTER TABLE user_org_roles
+    DROP CONSTRAINT IF EXISTS user_org_roles_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_org_roles_org_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_org_roles_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_org_roles_pkey,
+    ALTER COLUMN user_id DROP NOT NULL,
+    ALTER COLUMN org_id DROP NOT NULL,
+    ALTER COLUMN role_id DROP NOT NULL,
+    ALTER COLUMN status DROP DEFAULT;
+
+-- user_org_roles_duplicates is kept: its rows are the only copy of the
+-- memberships the up migration removed.
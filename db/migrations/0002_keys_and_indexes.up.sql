@@ -0,0 +1,69 @@
+-- Constraints created by the old gorm AutoMigrate, replaced below.
+ALTER TABLE user_org_roles DROP CONSTRAINT IF EXISTS fk_user_org_roles_user;
+ALTER TABLE user_org_roles DROP CONSTRAINT IF EXISTS fk_user_org_roles_org;
+ALTER TABLE user_org_roles DROP CONSTRAINT IF EXISTS fk_user_org_roles_role;
+ALTER TABLE user_org_roles DROP CONSTRAINT IF EXISTS fk_orgs_user_org_role;
+
+-- Keep a single membership per (user, org) before adding the primary key:
+-- an active one if there is any, otherwise the newest. The removed rows are
+-- kept in user_org_roles_duplicates for review.
+CREATE TABLE IF NOT EXISTS user_org_roles_duplicates AS
+SELECT * FROM user_org_roles WITH NO DATA;
+
+DO $$
+DECLARE
+    removed BIGINT;
+BEGIN
+    WITH ranked AS (
+        SELECT ctid AS row_id,
+               ROW_NUMBER() OVER (
+                   PARTITION BY user_id, org_id
+                   ORDER BY (status = 'active') DESC NULLS LAST, ctid DESC
+               ) AS rank
+        FROM user_org_roles
+        WHERE user_id IS NOT NULL AND org_id IS NOT NULL
+    ),
+    removed_rows AS (
+        DELETE FROM user_org_roles
+        WHERE ctid IN (SELECT row_id FROM ranked WHERE rank > 1)
+        RETURNING *
+    )
+    INSERT INTO user_org_roles_duplicates SELECT * FROM removed_rows;
+
+    GET DIAGNOSTICS removed = ROW_COUNT;
+    RAISE NOTICE 'removed % duplicate user_org_roles rows, see user_org_roles_duplicates', removed;
+END $$;
+
+DELETE FROM user_org_roles WHERE user_id IS NULL OR org_id IS NULL OR role_id IS NULL;
+
+ALTER TABLE user_org_roles
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN org_id SET NOT NULL,
+    ALTER COLUMN role_id SET NOT NULL,
+    ALTER COLUMN status SET DEFAULT '',
+    ADD CONSTRAINT user_org_roles_pkey PRIMARY KEY (user_id, org_id),
+    ADD CONSTRAINT user_org_roles_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT user_org_roles_org_id_fkey FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE,
+    ADD CONSTRAINT user_org_roles_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id);
+
+CREATE INDEX IF NOT EXISTS idx_user_org_roles_org_id ON user_org_roles (org_id);
+CREATE INDEX IF NOT EXISTS idx_user_org_roles_role_id ON user_org_roles (role_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orgs_slug ON orgs (slug);
+CREATE INDEX IF NOT EXISTS idx_orgs_deleted_at ON orgs (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+DELETE FROM role_permissions a
+USING role_permissions b
+WHERE a.ctid < b.ctid
+  AND a.role_id = b.role_id
+  AND a.permission_id = b.permission_id;
+
+ALTER TABLE role_permissions
+    ADD CONSTRAINT role_permissions_pkey PRIMARY KEY (role_id, permission_id),
+    ADD CONSTRAINT role_permissions_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+    ADD CONSTRAINT role_permissions_permission_id_fkey FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_permissions_method_path ON permissions (http_method, path);
@@ -0,0 +1,3 @@
+-- AddOrg rejects duplicate names; enforce it in the database so concurrent
+-- requests cannot both succeed.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orgs_name ON orgs (name);
@@ -0,0 +1,18 @@
+-- Org-scoped API keys for service accounts. Only the SHA-256 of the key is
+-- stored; prefix is the non-secret start of the key, shown in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    org_id       BIGINT NOT NULL REFERENCES orgs (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     TEXT NOT NULL,
+    scopes       JSONB NOT NULL DEFAULT '[]',
+    created_by   BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_org_id ON api_keys (org_id);
@@ -0,0 +1,20 @@
+-- Single-use tokens mailed to users (invitations, later email verification).
+-- Only the SHA-256 of the token is stored. For invitations, role_id and
+-- status are what accepting grants; they are never read from the token.
+CREATE TABLE IF NOT EXISTS action_tokens (
+    id          BIGSERIAL PRIMARY KEY,
+    token_hash  TEXT NOT NULL,
+    purpose     TEXT NOT NULL,
+    email       TEXT NOT NULL,
+    user_id     BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    org_id      BIGINT REFERENCES orgs (id) ON DELETE CASCADE,
+    role_id     BIGINT REFERENCES roles (id),
+    status      TEXT NOT NULL DEFAULT '',
+    created_by  BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_action_tokens_token_hash ON action_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_action_tokens_purpose_email ON action_tokens (purpose, email);
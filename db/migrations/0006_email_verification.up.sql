@@ -0,0 +1,6 @@
+-- Per-org settings, one row per org. Orgs without a row use the defaults.
+-- Orgs can refuse access to members who have not verified their email.
+CREATE TABLE IF NOT EXISTS org_settings (
+    org_id                 BIGINT PRIMARY KEY REFERENCES orgs (id) ON DELETE CASCADE,
+    require_verified_email BOOLEAN NOT NULL DEFAULT FALSE
+);
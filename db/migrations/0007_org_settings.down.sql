@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS audit_events;
+
+ALTER TABLE org_settings
+    DROP COLUMN IF EXISTS logo_url,
+    DROP COLUMN IF EXISTS primary_color,
+    DROP COLUMN IF EXISTS default_locale,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS default_role_id,
+    DROP COLUMN IF EXISTS join_policy,
+    DROP COLUMN IF EXISTS invitation_ttl_hours,
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS updated_at;
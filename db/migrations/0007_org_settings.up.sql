@@ -0,0 +1,26 @@
+-- The rest of the typed org settings.
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS logo_url             TEXT NOT NULL DEFAULT '';
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS primary_color        TEXT NOT NULL DEFAULT '';
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS default_locale       TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS timezone             TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS default_role_id      BIGINT REFERENCES roles (id) ON DELETE SET NULL;
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS join_policy          TEXT NOT NULL DEFAULT 'invite_only';
+-- NULL: the service's INVITATION_TOKEN_TTL
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS invitation_ttl_hours INT;
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS updated_by           BIGINT REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS updated_at           TIMESTAMPTZ;
+
+-- Who changed what, for changes that must be traceable.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id               BIGSERIAL PRIMARY KEY,
+    org_id           BIGINT REFERENCES orgs (id) ON DELETE CASCADE,
+    actor_user_id    BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    actor_api_key_id BIGINT REFERENCES api_keys (id) ON DELETE SET NULL,
+    action           TEXT NOT NULL,
+    target_type      TEXT NOT NULL,
+    target_id        TEXT NOT NULL,
+    details          JSONB,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_org_id_created_at ON audit_events (org_id, created_at);
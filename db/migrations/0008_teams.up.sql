@@ -0,0 +1,26 @@
+-- Teams group org members. A team may sit inside another team of the same
+-- org; the service limits how deep they nest.
+CREATE TABLE IF NOT EXISTS teams (
+    id          BIGSERIAL PRIMARY KEY,
+    org_id      BIGINT NOT NULL REFERENCES orgs (id) ON DELETE CASCADE,
+    parent_id   BIGINT REFERENCES teams (id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ
+);
+
+-- Sibling teams have distinct names; COALESCE makes top-level teams siblings.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_org_parent_name ON teams (org_id, COALESCE(parent_id, 0), lower(name));
+CREATE INDEX IF NOT EXISTS idx_teams_parent_id ON teams (parent_id);
+
+-- role is the team-level role: lead or member.
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id    BIGINT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members (user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orgs_parent_id;
+ALTER TABLE orgs DROP CONSTRAINT IF EXISTS orgs_parent_not_self;
+ALTER TABLE orgs DROP COLUMN IF EXISTS parent_id;
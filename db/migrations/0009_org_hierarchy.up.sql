@@ -0,0 +1,11 @@
+-- An org may belong to a parent org, e.g. an agency and its client orgs.
+-- Owners and admins of the parent administer the children.
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES orgs (id) ON DELETE SET NULL;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'orgs'::regclass AND conname = 'orgs_parent_not_self') THEN
+        ALTER TABLE orgs ADD CONSTRAINT orgs_parent_not_self CHECK (parent_id <> id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_orgs_parent_id ON orgs (parent_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS default_org_id;
+ALTER TABLE user_org_roles DROP COLUMN IF EXISTS last_accessed_at;
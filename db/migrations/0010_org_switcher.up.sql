@@ -0,0 +1,6 @@
+-- When the user last opened the org, recorded by the org access check; the
+-- org switcher lists recent orgs first.
+ALTER TABLE user_org_roles ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMPTZ;
+
+-- The org the UI opens after sign-in, pinned by the user.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_org_id BIGINT REFERENCES orgs (id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE orgs DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE orgs DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP COLUMN IF EXISTS platform_role;
@@ -0,0 +1,10 @@
+-- Platform roles are outside any org: superadmins are the service operator's
+-- support staff. Empty for everyone else.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS platform_role TEXT NOT NULL DEFAULT '';
+
+-- Suspended users cannot sign in to anything; suspended orgs cannot be
+-- accessed by their members or API keys.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE orgs ADD COLUMN IF NOT EXISTS suspension_reason TEXT NOT NULL DEFAULT '';
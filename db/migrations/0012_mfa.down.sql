@@ -0,0 +1,4 @@
+ALTER TABLE org_settings DROP COLUMN IF EXISTS require_mfa_for_admins;
+DROP TABLE IF EXISTS mfa_verifications;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
@@ -0,0 +1,33 @@
+-- TOTP enrollment, one per user. enabled_at stays NULL until the user has
+-- confirmed a code; last_used_step stops a code from being used twice.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id        BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    totp_secret    TEXT NOT NULL, -- encrypted with MFA_SECRET_KEY when set
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Single-use recovery codes; only their hashes are kept.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id_code_hash ON mfa_recovery_codes (user_id, code_hash);
+
+-- Access tokens that passed MFA, by hash, until the token expires.
+CREATE TABLE IF NOT EXISTS mfa_verifications (
+    token_hash  TEXT PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    method      TEXT NOT NULL,
+    verified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_verifications_user_id ON mfa_verifications (user_id);
+
+ALTER TABLE org_settings ADD COLUMN IF NOT EXISTS require_mfa_for_admins BOOLEAN NOT NULL DEFAULT FALSE;
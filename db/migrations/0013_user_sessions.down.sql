@@ -0,0 +1,3 @@
+ALTER TABLE user_org_roles DROP COLUMN IF EXISTS sessions_revoked_at;
+
+DROP TABLE IF EXISTS user_sessions;
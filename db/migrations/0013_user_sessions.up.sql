@@ -0,0 +1,22 @@
+-- Sign-in sessions seen by the service. session_key is the hash of the
+-- token's sid claim; a token without one is a session of its own, keyed by
+-- its jti or the token itself. Revoked sessions are kept until well after
+-- they expire so their tokens stay rejected.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_key    TEXT NOT NULL UNIQUE,
+    user_agent     TEXT NOT NULL DEFAULT '',
+    ip             TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_active_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    revoked_at     TIMESTAMPTZ,
+    revoked_by     BIGINT REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id_last_active_at ON user_sessions (user_id, last_active_at);
+
+-- Set when an org admin signs a member out of the org. Sessions that began
+-- before it are refused in that org, and only there.
+ALTER TABLE user_org_roles ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMPTZ;
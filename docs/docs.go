@@ -15,16 +15,16 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/o/{orgId}/members": {
+        "/api/admin/orgs": {
             "get": {
-                "description": "Validates user is, will query DB the orgs that current user is linked to and then returns them in JSON.",
+                "description": "Searches all orgs, including suspended and deleted ones, by id, name or slug. Platform admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orgs"
+                    "Admin"
                 ],
-                "summary": "GetOrgMembers",
+                "summary": "SearchOrgs",
                 "parameters": [
                     {
                         "type": "string",
@@ -33,9 +33,56 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "OrgID",
+                        "description": "Page size, at most 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.OrgsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orgs/{orgId}": {
+            "get": {
+                "description": "Returns an org with all its memberships, whatever their status. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "GetOrg",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
                         "name": "orgId",
                         "in": "path",
                         "required": true
@@ -45,15 +92,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/org.OrgMembersResponse"
+                            "$ref": "#/definitions/admin.OrgDetailResponse"
                         }
                     }
                 }
             }
         },
-        "/api/o/{orgId}/users/invite/{email}/{roleId}": {
-            "get": {
-                "description": "Validates email, role ID in request, checks in DB if req email exists with req orgId, if not generates a JWT token, send via email a UI app URL containing the token.",
+        "/api/admin/orgs/{orgId}/suspend": {
+            "post": {
+                "description": "Suspends an org: its members and API keys cannot access it until it is unsuspended. Platform admins only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -61,9 +108,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "InviteUser",
+                "summary": "SuspendOrg",
                 "parameters": [
                     {
                         "type": "string",
@@ -74,22 +121,53 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "OrgID",
+                        "description": "Org ID",
                         "name": "orgId",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "SuspendRequest",
+                        "name": "SuspendRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/admin.SuspendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.OrgResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orgs/{orgId}/unsuspend": {
+            "post": {
+                "description": "Lifts an org's suspension. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "UnsuspendOrg",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "RoleID",
-                        "name": "roleId",
+                        "description": "Org ID",
+                        "name": "orgId",
                         "in": "path",
                         "required": true
                     }
@@ -98,25 +176,22 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.StatusResponse"
+                            "$ref": "#/definitions/admin.OrgResponse"
                         }
                     }
                 }
             }
         },
-        "/api/orgs": {
-            "post": {
-                "description": "Validates user id, org name and org size, checks if org exists in DB by name or slug, if not a new organization with trial subscription will be created and then the created ID will be returned.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/admin/users": {
+            "get": {
+                "description": "Searches users across all orgs by id, email, username or name. Platform admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orgs"
+                    "Admin"
                 ],
-                "summary": "Add Org",
+                "summary": "SearchUsers",
                 "parameters": [
                     {
                         "type": "string",
@@ -126,35 +201,44 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "AddOrgRequest",
-                        "name": "AddOrgRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/org.AddOrgRequest"
-                        }
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/org.OrgResponse"
+                            "$ref": "#/definitions/admin.UsersResponse"
                         }
                     }
                 }
             }
         },
-        "/api/orgs/me": {
+        "/api/admin/users/{userId}": {
             "get": {
-                "description": "Validates user is, will query DB the orgs that current user is linked to and then returns them in JSON.",
+                "description": "Returns a user with all their memberships and open invitations. Platform admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Orgs"
+                    "Admin"
                 ],
-                "summary": "FindMyOrgs",
+                "summary": "GetUser",
                 "parameters": [
                     {
                         "type": "string",
@@ -162,24 +246,28 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/org.OrgWithRole"
-                            }
+                            "$ref": "#/definitions/admin.UserDetailResponse"
                         }
                     }
                 }
             }
         },
-        "/api/users/invite/accept/{token}": {
+        "/api/admin/users/{userId}/impersonate": {
             "post": {
-                "description": "Validates token, username, firstName, lastName, password and confirmPassword, then check in DB if user with same email and org is already connected if not creates User, Profile and Org Relationship and returns created user ID in response.",
+                "description": "Issues a short-lived token for acting as the user, e.g. to reproduce a problem they report. Requests made with it are audited, and it cannot change passwords, emails, roles or API keys. Superadmins and suspended users cannot be impersonated. Platform admins only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -187,24 +275,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "InviteAccept",
+                "summary": "ImpersonateUser",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Token",
-                        "name": "token",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "AcceptInvitationRequest",
-                        "name": "AcceptInvitationRequest",
+                        "description": "ImpersonateRequest",
+                        "name": "ImpersonateRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/users.AcceptInvitationRequest"
+                            "$ref": "#/definitions/admin.ImpersonateRequest"
                         }
                     }
                 ],
@@ -212,22 +307,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.AcceptInvitationResponse"
+                            "$ref": "#/definitions/admin.ImpersonationResponse"
                         }
                     }
                 }
             }
         },
-        "/o/{orgId}/users/change-user-role": {
+        "/api/admin/users/{userId}/memberships/{orgId}": {
             "put": {
-                "description": "Validates org id and user id, and new role id, will query DB in users for user by user id, then tries to change the role from admin to owner or vice-versa.",
+                "description": "Creates or corrects a user's membership in an org, e.g. to activate a stuck invitation. Changing the status away from invited discards the user's open invitations to the org. Platform admins only.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "ChangeUserRole",
+                "summary": "RepairMembership",
                 "parameters": [
                     {
                         "type": "string",
@@ -236,6 +334,13 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "Org ID",
@@ -244,12 +349,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "ChangeUserRoleRequest",
-                        "name": "ChangeUserRoleRequest",
+                        "description": "RepairMembershipRequest",
+                        "name": "RepairMembershipRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/users.ChangeUserRoleRequest"
+                            "$ref": "#/definitions/admin.RepairMembershipRequest"
                         }
                     }
                 ],
@@ -257,22 +362,20 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.StatusResponse"
+                            "$ref": "#/definitions/admin.MembershipResponse"
                         }
                     }
                 }
-            }
-        },
-        "/o/{orgId}/users/change-user-status": {
-            "put": {
-                "description": "Validates org id and user id, and status, will try to find user by user id, then tries to change the status.",
+            },
+            "delete": {
+                "description": "Removes a user from an org, with their team memberships and open invitations there. Platform admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "ChangeUserStatus",
+                "summary": "RemoveMembership",
                 "parameters": [
                     {
                         "type": "string",
@@ -281,20 +384,66 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "Org ID",
                         "name": "orgId",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{userId}/suspend": {
+            "post": {
+                "description": "Suspends a user: all their requests are refused until they are unsuspended. Platform admins only; they cannot suspend themselves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "SuspendUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "description": "ChangeUserStatusRequest",
-                        "name": "ChangeUserStatusRequest",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SuspendRequest",
+                        "name": "SuspendRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/users.ChangeUserStatusRequest"
+                            "$ref": "#/definitions/admin.SuspendRequest"
                         }
                     }
                 ],
@@ -302,81 +451,2485 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.StatusResponse"
+                            "$ref": "#/definitions/admin.UserResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "org.AddOrgRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "size": {
-                    "type": "string"
-                }
-            }
-        },
-        "org.OrgMembers": {
-            "type": "object",
-            "properties": {
-                "user": {
-                    "$ref": "#/definitions/org.UserResponse"
-                },
-                "userOrgRole": {
-                    "$ref": "#/definitions/org.UserOrgRoleResponse"
-                }
-            }
         },
-        "org.OrgMembersResponse": {
-            "type": "object",
-            "properties": {
-                "orgMembers": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/org.OrgMembers"
+        "/api/admin/users/{userId}/unsuspend": {
+            "post": {
+                "description": "Lifts a user's suspension. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "UnsuspendUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.UserResponse"
+                        }
                     }
                 }
             }
         },
-        "org.OrgResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
+        "/api/admin/users/{userId}/verify-email": {
+            "post": {
+                "description": "Marks the user's email address as verified without a verification link, e.g. when mail does not reach them. Outstanding verification links stop working. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "VerifyUserEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.UserResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invitations/{token}": {
+            "get": {
+                "description": "Returns what the UI shows before accepting an invitation: the org, who sent it, the invited email and whether the invited user still has to sign up. The token is not consumed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GetInvitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.InvitationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invitations/{token}/accept": {
+            "post": {
+                "description": "Consumes the single-use invitation token and adds the signed-in user to the org with the role and status stored for it. The invitation must have been sent to the signed-in user's email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "AcceptInvitationAsUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.AcceptInvitationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/api-keys": {
+            "get": {
+                "description": "Lists the org's API keys, including revoked and expired ones. Keys are identified by prefix; the secret is never returned again. Owners and admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "APIKeys"
+                ],
+                "summary": "ListAPIKeys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apikeys.ListAPIKeysResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates an org-scoped API key for service accounts. The full key is only returned in this response; send it as \"Authorization: ApiKey \u003ckey\u003e\". Owners and admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "APIKeys"
+                ],
+                "summary": "CreateAPIKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CreateAPIKeyRequest",
+                        "name": "CreateAPIKeyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apikeys.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/apikeys.CreateAPIKeyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/api-keys/{keyId}": {
+            "delete": {
+                "description": "Revokes an API key; requests using it fail from then on. Owners and admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "APIKeys"
+                ],
+                "summary": "RevokeAPIKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apikeys.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/children": {
+            "get": {
+                "description": "Returns the tree of orgs below the org. Owners and admins of the org administer all of them. Owners and admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "GetOrgChildren",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgChildrenResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/members": {
+            "get": {
+                "description": "Validates user is, will query DB the orgs that current user is linked to and then returns them in JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "GetOrgMembers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only members of this team",
+                        "name": "teamId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "With teamId, also members of the teams nested inside it",
+                        "name": "includeSubteams",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgMembersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/parent": {
+            "put": {
+                "description": "Moves the org below another org, or detaches it with parentId 0. Owners and admins of the new parent org then administer this org. Requires owner or admin in this org and, when attaching, in the new parent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "SetOrgParent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SetParentRequest",
+                        "name": "SetParentRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/org.SetParentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/settings": {
+            "get": {
+                "description": "Returns the branding and membership settings of the org. Orgs that never changed them get the defaults.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "GetOrgSettings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgSettingsResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes the branding and membership settings of the org. Fields left out of the request are not changed. Owners and admins only; every change is recorded in the audit log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "UpdateOrgSettings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "UpdateOrgSettingsRequest",
+                        "name": "UpdateOrgSettingsRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/org.UpdateOrgSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgSettingsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/teams": {
+            "get": {
+                "description": "Lists all teams of the org. Nesting is given by parentId; top-level teams have none.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "ListTeams",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.ListTeamsResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a team in the org, or inside another team when parentId is set. Top-level teams are created by owners and admins; sub-teams also by leads of the parent team. Teams nest at most teams.maxDepth deep.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "CreateTeam",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CreateTeamRequest",
+                        "name": "CreateTeamRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/teams.CreateTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/teams.TeamResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/teams/{teamId}": {
+            "get": {
+                "description": "Returns a team with its direct members and their team roles.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "GetTeam",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "teamId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.TeamDetailResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a team and its memberships. Teams with sub-teams cannot be deleted. Top-level teams are deleted by owners and admins; sub-teams also by leads of the parent team.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "DeleteTeam",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "teamId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.StatusResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Renames, describes or moves a team. Requires the lead role in the team or a team above it; moving the team also requires managing both its current and its new parent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "UpdateTeam",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "teamId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "UpdateTeamRequest",
+                        "name": "UpdateTeamRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/teams.UpdateTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.TeamResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/teams/{teamId}/members/{userId}": {
+            "put": {
+                "description": "Adds an active org member to the team, or changes their team role. Requires the lead role in the team or a team above it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "SetTeamMember",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "teamId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SetTeamMemberRequest",
+                        "name": "SetTeamMemberRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/teams.SetTeamMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.TeamMemberResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a user from the team. Requires the lead role in the team or a team above it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "RemoveTeamMember",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "teamId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/teams.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/o/{orgId}/users/invite/{email}/{roleId}": {
+            "get": {
+                "description": "Validates email, role ID in request (the org's default role if left out), checks in DB if req email exists with req orgId, if not generates a single-use invitation token (stored hashed, with the role and status to grant), send via email a UI app URL containing the token. Only owners can invite owners and only owners and admins can invite admins. The invitation expiry, whether the member needs approval and the email branding come from the org settings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "InviteUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "OrgID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "RoleID",
+                        "name": "roleId",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orgs": {
+            "post": {
+                "description": "Validates user id, org name and org size, checks if org exists in DB by name or slug, if not a new organization with trial subscription will be created and then the created ID will be returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "Add Org",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "AddOrgRequest",
+                        "name": "AddOrgRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/org.AddOrgRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.OrgResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orgs/me": {
+            "get": {
+                "description": "Lists the orgs the current user belongs to for the org switcher, most recently accessed first, with the user's role and membership status. Only active memberships are listed unless status says otherwise. Deleted orgs are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "FindMyOrgs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "active (default), inactive, invited, pending, rejected or all",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/org.OrgWithRole"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orgs/me/default": {
+            "put": {
+                "description": "Pins the org the UI opens first for the current user, or unpins it with orgId 0. The user must be an active member of the org.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orgs"
+                ],
+                "summary": "SetDefaultOrg",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "SetDefaultOrgRequest",
+                        "name": "SetDefaultOrgRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/org.SetDefaultOrgRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/org.DefaultOrgResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/confirm-email/{token}": {
+            "post": {
+                "description": "Consumes the single-use token from an email change link and sets the user's email to the address it was sent to, which is then verified.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ConfirmEmailChange",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/invite/accept/{token}": {
+            "post": {
+                "description": "Validates token, username, firstName, lastName, password and confirmPassword, then consumes the single-use invitation token, completes the invited user's registration, sends an email verification link and activates the invited membership with the role and status stored for it. A token can only be accepted once. If the invited email already has an account, the invitation must be accepted while signed in instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "InviteAccept",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "AcceptInvitationRequest",
+                        "name": "AcceptInvitationRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.AcceptInvitationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.AcceptInvitationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me": {
+            "get": {
+                "description": "Returns the profile of the current user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GetMe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.ProfileResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Updates the username, first name, last name and phone of the current user. Fields left out of the request are not changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "UpdateMe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "UpdateProfileRequest",
+                        "name": "UpdateProfileRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.ProfileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/avatar": {
+            "put": {
+                "description": "Replaces the avatar of the current user. Accepts a JPEG, PNG, GIF or WebP image in the avatar form field, between 64 and 4096 pixels wide and high, and stores square thumbnails of it. Returns the profile with signed URLs of the thumbnails.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "UploadAvatar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.ProfileResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the avatar of the current user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "DeleteAvatar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.ProfileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/email": {
+            "post": {
+                "description": "Checks the password of the current user and sends a confirmation link to the new email address. The email is only changed once the link is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ChangeEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "ChangeEmailRequest",
+                        "name": "ChangeEmailRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa": {
+            "get": {
+                "description": "Returns whether the current user has MFA enabled, how many recovery codes are left and whether the access token in use has passed MFA.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "GetMFAStatus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.MFAStatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa/disable": {
+            "post": {
+                "description": "Turns MFA off for the current user, confirmed with a code from the authenticator app or a recovery code. Refused while the user is an owner or admin of an org that requires MFA.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "DisableMFA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "VerifyRequest",
+                        "name": "VerifyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfa.VerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa/recovery-codes": {
+            "post": {
+                "description": "Replaces all recovery codes of the current user with new ones, confirmed with a code from the authenticator app. The new codes are not shown again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "RegenerateRecoveryCodes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "CodeRequest",
+                        "name": "CodeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfa.CodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.RecoveryCodesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa/totp": {
+            "post": {
+                "description": "Creates a new TOTP secret for the current user, replacing an unconfirmed one. Show provisioningUri as a QR code, then confirm with a code from the app. Fails if MFA is already enabled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "StartMFAEnrollment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.EnrollmentResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa/totp/confirm": {
+            "post": {
+                "description": "Enables MFA once a code from the authenticator app matches, and returns single-use recovery codes. They are not shown again. The access token in use counts as verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "ConfirmMFAEnrollment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "CodeRequest",
+                        "name": "CodeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfa.CodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.RecoveryCodesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/mfa/verify": {
+            "post": {
+                "description": "Checks a code from the authenticator app, or an unused recovery code, and marks the access token in use as verified until it expires. Call it after each sign-in; tokens are accepted unverified everywhere else. Orgs that require MFA let their owners and admins use privileged routes only with a verified token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "VerifyMFA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "VerifyRequest",
+                        "name": "VerifyRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfa.VerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfa.VerificationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/password": {
+            "post": {
+                "description": "Checks the current password of the current user and replaces it with the new one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ChangePassword",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "ChangePasswordRequest",
+                        "name": "ChangePasswordRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/sessions": {
+            "get": {
+                "description": "Lists the current user's sessions that are neither signed out nor expired, most recently active first, with the device, IP address and last activity of each. Tokens of one sign-in are told apart by their sid claim; a token without one is listed as a session of its own. Impersonation tokens get a 403 sessions_unsupported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ListSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.SessionsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/sessions/{sessionId}": {
+            "delete": {
+                "description": "Signs the current user out of one of their sessions; its tokens are rejected from then on. The current session can be revoked too. Not available to impersonation tokens.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "RevokeSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/verify-email/resend": {
+            "post": {
+                "description": "Sends the current user a new email verification link. Links sent earlier stop working.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ResendVerificationEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/verify-email/{token}": {
+            "post": {
+                "description": "Consumes the single-use token from a verification email and marks the user's email as verified. The token only works while the user still has the email it was sent to.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "VerifyEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/o/{orgId}/users/change-user-role": {
+            "put": {
+                "description": "Validates org id and user id, and new role id, will query DB in users for user by user id, then tries to change the role from admin to owner or vice-versa. Only owners can change roles, and the last active owner cannot be demoted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ChangeUserRole",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ChangeUserRoleRequest",
+                        "name": "ChangeUserRoleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.ChangeUserRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/o/{orgId}/users/change-user-status": {
+            "put": {
+                "description": "Validates org id and user id, and status, will try to find user by user id, then tries to change the status. Only owners, admins and API keys with the users:manage scope can change statuses, and only owners can change an owner's status. The last active owner cannot be deactivated or rejected. With revokeSessions, a deactivated or rejected member is also signed out of this org: sessions they began before the change are refused here, even once they are reactivated, but keep working in their other orgs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "ChangeUserStatus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Key(e.g Bearer key)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Org ID",
+                        "name": "orgId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ChangeUserStatusRequest",
+                        "name": "ChangeUserStatusRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.ChangeUserStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.StatusResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "admin.ImpersonateRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "admin.ImpersonationResponse": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "impersonationId": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "admin.InvitationResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "orgId": {
+                    "type": "integer"
+                },
+                "roleId": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.MembershipResponse": {
+            "type": "object",
+            "properties": {
+                "lastAccessedAt": {
+                    "type": "string"
+                },
+                "orgId": {
+                    "type": "integer"
+                },
+                "orgName": {
+                    "type": "string"
+                },
+                "orgSlug": {
+                    "type": "string"
+                },
+                "roleId": {
+                    "type": "integer"
+                },
+                "roleName": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.OrgDetailResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "memberCount": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.OrgMemberResponse"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "suspendedAt": {
+                    "type": "string"
+                },
+                "suspensionReason": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.OrgMemberResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "roleId": {
+                    "type": "integer"
+                },
+                "roleName": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "admin.OrgResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "memberCount": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "suspendedAt": {
+                    "type": "string"
+                },
+                "suspensionReason": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.OrgsResponse": {
+            "type": "object",
+            "properties": {
+                "orgs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.OrgResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "admin.RepairMembershipRequest": {
+            "type": "object",
+            "properties": {
+                "roleId": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "invited",
+                        "pending",
+                        "rejected"
+                    ]
+                }
+            }
+        },
+        "admin.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "admin.SuspendRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "admin.UserDetailResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invitations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.InvitationResponse"
+                    }
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "memberships": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.MembershipResponse"
+                    }
+                },
+                "platformRole": {
+                    "type": "string"
+                },
+                "suspendedAt": {
+                    "type": "string"
+                },
+                "suspensionReason": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "verifiedEmail": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "admin.UserResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "platformRole": {
+                    "type": "string"
+                },
+                "suspendedAt": {
+                    "type": "string"
+                },
+                "suspensionReason": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "verifiedEmail": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "admin.UsersResponse": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.UserResponse"
+                    }
+                }
+            }
+        },
+        "apikeys.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "apikeys.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "apikeys.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "apikeys.ListAPIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "apiKeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apikeys.APIKeyResponse"
+                    }
+                }
+            }
+        },
+        "apikeys.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "mfa.CodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "mfa.EnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "provisioningUri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "mfa.MFAStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "enabledAt": {
+                    "type": "string"
+                },
+                "recoveryCodesRemaining": {
+                    "type": "integer"
+                },
+                "verified": {
+                    "description": "Verified is whether the access token in use has passed MFA.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "mfa.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mfa.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "mfa.VerificationResponse": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "recoveryCodesRemaining": {
+                    "description": "RecoveryCodesRemaining is set when a recovery code was used.",
+                    "type": "integer"
+                }
+            }
+        },
+        "mfa.VerifyRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "recoveryCode": {
+                    "type": "string",
+                    "maxLength": 20
+                }
+            }
+        },
+        "org.AddOrgRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "size"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "requireVerifiedEmail": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "org.DefaultOrgResponse": {
+            "type": "object",
+            "properties": {
+                "orgId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "org.OrgChildrenResponse": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/org.OrgTreeNode"
+                    }
+                }
+            }
+        },
+        "org.OrgMembers": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/org.UserResponse"
+                },
+                "userOrgRole": {
+                    "$ref": "#/definitions/org.UserOrgRoleResponse"
+                }
+            }
+        },
+        "org.OrgMembersResponse": {
+            "type": "object",
+            "properties": {
+                "orgMembers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/org.OrgMembers"
+                    }
+                }
+            }
+        },
+        "org.OrgResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "requireVerifiedEmail": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "org.OrgSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "defaultLocale": {
+                    "type": "string"
+                },
+                "defaultRoleId": {
+                    "type": "integer"
+                },
+                "invitationTtlHours": {
+                    "type": "integer"
+                },
+                "joinPolicy": {
+                    "type": "string"
+                },
+                "logoUrl": {
+                    "type": "string"
+                },
+                "orgId": {
+                    "type": "integer"
+                },
+                "primaryColor": {
+                    "type": "string"
+                },
+                "requireMfaForAdmins": {
+                    "type": "boolean"
+                },
+                "requireVerifiedEmail": {
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "org.OrgTreeNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/org.OrgTreeNode"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "org.OrgWithRole": {
+            "type": "object",
+            "properties": {
+                "isDefault": {
+                    "type": "boolean"
+                },
+                "lastAccessedAt": {
+                    "type": "string"
+                },
+                "memberCount": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "orgId": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "roleId": {
+                    "type": "integer"
                 },
-                "size": {
+                "roleName": {
                     "type": "string"
                 },
                 "slug": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
                 }
             }
         },
-        "org.OrgWithRole": {
+        "org.SetDefaultOrgRequest": {
             "type": "object",
+            "required": [
+                "orgId"
+            ],
             "properties": {
-                "name": {
+                "orgId": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "org.SetParentRequest": {
+            "type": "object",
+            "required": [
+                "parentId"
+            ],
+            "properties": {
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "org.UpdateOrgSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "defaultLocale": {
                     "type": "string"
                 },
-                "orgId": {
+                "defaultRoleId": {
                     "type": "integer"
                 },
-                "roleId": {
-                    "type": "integer"
+                "invitationTtlHours": {
+                    "type": "integer",
+                    "maximum": 720
                 },
-                "slug": {
+                "joinPolicy": {
+                    "type": "string",
+                    "enum": [
+                        "invite_only",
+                        "approval_required"
+                    ]
+                },
+                "logoUrl": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "primaryColor": {
                     "type": "string"
                 },
-                "userId": {
-                    "type": "integer"
+                "requireMfaForAdmins": {
+                    "type": "boolean"
+                },
+                "requireVerifiedEmail": {
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string"
                 }
             }
         },
@@ -406,6 +2959,12 @@ const docTemplate = `{
                 "avatarImgKey": {
                     "type": "string"
                 },
+                "avatarUrls": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "email": {
                     "type": "string"
                 },
@@ -429,10 +2988,99 @@ const docTemplate = `{
                 }
             }
         },
-        "users.AcceptInvitationRequest": {
+        "teams.CreateTeamRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "confirmPassword": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "teams.ListTeamsResponse": {
+            "type": "object",
+            "properties": {
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/teams.TeamResponse"
+                    }
+                }
+            }
+        },
+        "teams.SetTeamMemberRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "lead",
+                        "member"
+                    ]
+                }
+            }
+        },
+        "teams.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "teams.TeamDetailResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "memberCount": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/teams.TeamMemberResponse"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "teams.TeamMemberResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
                     "type": "string"
                 },
                 "firstName": {
@@ -441,6 +3089,80 @@ const docTemplate = `{
                 "lastName": {
                     "type": "string"
                 },
+                "role": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "teams.TeamResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "memberCount": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "teams.UpdateTeamRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "users.AcceptInvitationRequest": {
+            "type": "object",
+            "required": [
+                "confirmPassword",
+                "firstName",
+                "lastName",
+                "password",
+                "token",
+                "username"
+            ],
+            "properties": {
+                "confirmPassword": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "password": {
                     "type": "string"
                 },
@@ -466,17 +3188,57 @@ const docTemplate = `{
                 },
                 "status": {
                     "type": "string"
+                }
+            }
+        },
+        "users.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "newEmail",
+                "password"
+            ],
+            "properties": {
+                "newEmail": {
+                    "type": "string",
+                    "maxLength": 254
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "confirmPassword",
+                "currentPassword",
+                "newPassword"
+            ],
+            "properties": {
+                "confirmPassword": {
+                    "type": "string"
                 },
-                "token": {
+                "currentPassword": {
+                    "type": "string"
+                },
+                "newPassword": {
                     "type": "string"
                 }
             }
         },
         "users.ChangeUserRoleRequest": {
             "type": "object",
+            "required": [
+                "newRoleId",
+                "userId"
+            ],
             "properties": {
                 "newRoleId": {
-                    "type": "integer"
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2
+                    ]
                 },
                 "userId": {
                     "type": "integer"
@@ -485,15 +3247,129 @@ const docTemplate = `{
         },
         "users.ChangeUserStatusRequest": {
             "type": "object",
+            "required": [
+                "status",
+                "userId"
+            ],
             "properties": {
+                "revokeSessions": {
+                    "type": "boolean"
+                },
                 "status": {
-                    "type": "string"
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "rejected"
+                    ]
                 },
                 "userId": {
                     "type": "integer"
                 }
             }
         },
+        "users.InvitationResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "invitedBy": {
+                    "type": "string"
+                },
+                "orgName": {
+                    "type": "string"
+                },
+                "orgSlug": {
+                    "type": "string"
+                },
+                "roleId": {
+                    "type": "integer"
+                },
+                "signupRequired": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "users.ProfileResponse": {
+            "type": "object",
+            "properties": {
+                "avatarImgKey": {
+                    "type": "string"
+                },
+                "avatarUrls": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "verifiedEmail": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "users.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current is the session making the request.",
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastActiveAt": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.SessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.SessionResponse"
+                    }
+                }
+            }
+        },
         "users.StatusResponse": {
             "type": "object",
             "properties": {
@@ -501,6 +3377,26 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "users.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "firstName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
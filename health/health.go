@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const checkTimeout = 2 * time.Second
+
+// Check is a named dependency probe used by the readiness endpoint.
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+type Health struct {
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func New(checks ...Check) *Health {
+	return &Health{checks: checks}
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop routing new
+// requests while in-flight ones drain.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness reports that the process is up and serving HTTP.
+func (h *Health) Liveness(c *fiber.Ctx) error {
+	return c.JSON(Response{Status: "ok"})
+}
+
+// Readiness runs every check and returns 503 if any fails or the server is
+// shutting down.
+func (h *Health) Readiness(c *fiber.Ctx) error {
+	if h.shuttingDown.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(Response{Status: "shutting down"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), checkTimeout)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(h.checks))
+	for _, check := range h.checks {
+		go func(check Check) {
+			results <- result{name: check.Name, err: check.Fn(ctx)}
+		}(check)
+	}
+
+	res := Response{Status: "ok", Checks: map[string]string{}}
+	for range h.checks {
+		r := <-results
+		if r.err != nil {
+			res.Status = "unavailable"
+			res.Checks[r.name] = r.err.Error()
+			continue
+		}
+		res.Checks[r.name] = "ok"
+	}
+
+	if res.Status != "ok" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(res)
+	}
+	return c.JSON(res)
+}
+
+// DBCheck pings Postgres through gorm's connection pool.
+func DBCheck(db *gorm.DB) Check {
+	return Check{
+		Name: "postgres",
+		Fn: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// SMTPCheck verifies the mail server accepts TCP connections. It does not
+// authenticate, to keep frequent probes cheap.
+func SMTPCheck(host string, port int) Check {
+	return Check{
+		Name: "mail",
+		Fn: func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", host, port))
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package helper
+
+import (
+	"context"
+	"sync"
+)
+
+// Background runs long-lived goroutines (cleanup loops, async jobs) that
+// must be stopped and drained on shutdown.
+type Background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBackground() *Background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Background{ctx: ctx, cancel: cancel}
+}
+
+// Go starts fn in a goroutine. fn must return once ctx is cancelled.
+func (b *Background) Go(fn func(ctx context.Context)) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn(b.ctx)
+	}()
+}
+
+// Shutdown cancels the workers and waits for them to return, or for ctx to
+// expire, whichever comes first.
+func (b *Background) Shutdown(ctx context.Context) error {
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,167 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// ErrorKind classifies domain errors; HTTPStatus maps each kind to a status
+// code.
+type ErrorKind string
+
+const (
+	KindValidation    ErrorKind = "validation"
+	KindUnauthorized  ErrorKind = "unauthorized"
+	KindForbidden     ErrorKind = "forbidden"
+	KindNotFound      ErrorKind = "not_found"
+	KindConflict      ErrorKind = "conflict"
+	KindLimitExceeded ErrorKind = "limit_exceeded"
+	KindRateLimited   ErrorKind = "rate_limited"
+)
+
+// Sentinels for errors.Is, e.g. errors.Is(err, helper.ErrNotFound).
+var (
+	ErrValidation    = &Error{Kind: KindValidation}
+	ErrUnauthorized  = &Error{Kind: KindUnauthorized}
+	ErrForbidden     = &Error{Kind: KindForbidden}
+	ErrNotFound      = &Error{Kind: KindNotFound}
+	ErrConflict      = &Error{Kind: KindConflict}
+	ErrLimitExceeded = &Error{Kind: KindLimitExceeded}
+	ErrRateLimited   = &Error{Kind: KindRateLimited}
+)
+
+var kindStatus = map[ErrorKind]int{
+	KindValidation:    http.StatusBadRequest,
+	KindUnauthorized:  http.StatusUnauthorized,
+	KindForbidden:     http.StatusForbidden,
+	KindNotFound:      http.StatusNotFound,
+	KindConflict:      http.StatusConflict,
+	KindLimitExceeded: http.StatusPaymentRequired,
+	KindRateLimited:   http.StatusTooManyRequests,
+}
+
+// HTTPStatus is the status the response to err gets. Unknown errors are 500.
+// Middleware that only observes requests uses it before the error has been
+// rendered.
+func HTTPStatus(err error) int {
+	var appErr *Error
+	var fiberErr *fiber.Error
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &appErr):
+		if status, ok := kindStatus[appErr.Kind]; ok {
+			return status
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &fiberErr):
+		return fiberErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+const pgUniqueViolation = "23505"
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error returned by the services. Code is a stable,
+// machine-readable identifier ("org_name_taken"); Message is shown to users.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return string(e.Kind)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the kind sentinels above, so any not-found error satisfies
+// errors.Is(err, ErrNotFound) regardless of its code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == "" && t.Message == "" && t.Kind == e.Kind
+}
+
+func ValidationError(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+// FieldRequired is a validation error for a single missing field.
+func FieldRequired(field string) *Error {
+	return ValidationError("validation_failed", field+" is required", FieldError{Field: field, Message: "is required"})
+}
+
+// FieldInvalid is a validation error for a single malformed field.
+func FieldInvalid(field, message string) *Error {
+	return ValidationError("validation_failed", field+" "+message, FieldError{Field: field, Message: message})
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func LimitExceeded(code, message string) *Error {
+	return &Error{Kind: KindLimitExceeded, Code: code, Message: message}
+}
+
+func RateLimited(code, message string) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+// HasCode reports whether err is an *Error with the given code.
+func HasCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// NotFoundIfMissing turns gorm.ErrRecordNotFound into a NotFound error with
+// the given code and message and returns any other error unchanged.
+func NotFoundIfMissing(err error, code, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Error{Kind: KindNotFound, Code: code, Message: message, Err: err}
+	}
+	return err
+}
+
+// UniqueViolation reports whether err is a Postgres unique constraint
+// violation and, if so, the name of the violated constraint or index.
+func UniqueViolation(err error) (constraint string, ok bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return pgErr.ConstraintName, true
+	}
+	return "", false
+}
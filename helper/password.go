@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"context"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"org-service/tracing"
+)
+
+// HashPassword bcrypt-hashes password at the default cost. It is traced
+// because hashing is deliberately slow and shows up in request latency.
+func HashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.GenerateFromPassword")
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	tracing.End(span, err)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the bcrypt hash.
+func CheckPassword(ctx context.Context, hash, password string) bool {
+	_, span := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	// A mismatch is the expected outcome of a wrong password, not a failure.
+	tracing.End(span, nil)
+	return err == nil
+}
@@ -0,0 +1,198 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Org size buckets accepted by the `orgsize` rule.
+var OrgSizes = []string{"1-10", "11-50", "51-200", "201-500", "501-1000", "1000+"}
+
+var (
+	slugRegex     = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,29}$`)
+)
+
+// Validator validates request structs from their `validate` struct tags.
+// Besides the go-playground/validator built-ins it understands:
+//
+//	slug      lowercase letters and digits separated by single hyphens
+//	orgsize   one of OrgSizes
+//	username  3-30 letters, digits, '.', '_' or '-', starting alphanumeric
+//	password  at least 8 characters with upper, lower case and a digit
+//	roleid    id of an existing row in roles
+type Validator struct {
+	validate *validator.Validate
+}
+
+func NewValidator(db *gorm.DB) *Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name so errors match the request body.
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return strings.ToLower(fld.Name[:1]) + fld.Name[1:]
+		}
+		return name
+	})
+
+	v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugRegex.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("orgsize", func(fl validator.FieldLevel) bool {
+		size := fl.Field().String()
+		for _, s := range OrgSizes {
+			if s == size {
+				return true
+			}
+		}
+		return false
+	})
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernameRegex.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return isStrongPassword(fl.Field().String())
+	})
+	v.RegisterValidationCtx("roleid", func(ctx context.Context, fl validator.FieldLevel) bool {
+		var count int64
+		if err := db.WithContext(ctx).Table("roles").Where("id = ? AND deleted_at IS NULL", fl.Field().Int()).Count(&count).Error; err != nil {
+			recordQueryError(ctx, err)
+			return false
+		}
+		return count > 0
+	})
+
+	return &Validator{validate: v}
+}
+
+// queryErrorKey holds the first database error of the rules run by one
+// Struct call, so an outage is not reported as an invalid field.
+type queryErrorKey struct{}
+
+func recordQueryError(ctx context.Context, err error) {
+	if dst, ok := ctx.Value(queryErrorKey{}).(*error); ok && *dst == nil {
+		*dst = err
+	}
+}
+
+// Struct validates req and returns a validation *Error listing every
+// failing field, or nil. ctx is passed to rules that query the database;
+// if one of those queries fails, its error is returned instead.
+func (v *Validator) Struct(ctx context.Context, req interface{}) error {
+	var queryErr error
+	err := v.validate.StructCtx(context.WithValue(ctx, queryErrorKey{}, &queryErr), req)
+	if queryErr != nil {
+		return fmt.Errorf("validating request: %w", queryErr)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+
+	message := "request validation failed"
+	if len(fields) == 1 {
+		message = fields[0].Field + " " + fields[0].Message
+	}
+	return ValidationError("validation_failed", message, fields...)
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	tag, param := fe.Tag(), fe.Param()
+	// "eq=|rule" also accepts the empty value, which clears optional
+	// settings; explain the rule.
+	if alternatives := strings.Split(tag, "|"); len(alternatives) > 1 {
+		for _, alt := range alternatives {
+			if !strings.HasPrefix(alt, "eq=") {
+				tag, param, _ = strings.Cut(alt, "=")
+				break
+			}
+		}
+	}
+
+	switch tag {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", param)
+		}
+		return fmt.Sprintf("must be at least %s", param)
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", param)
+		}
+		return fmt.Sprintf("must be at most %s", param)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "eqfield":
+		return "must match " + lowerFirst(param)
+	case "slug":
+		return "must contain only lowercase letters, digits and single hyphens"
+	case "orgsize":
+		return "must be one of " + strings.Join(OrgSizes, ", ")
+	case "username":
+		return "must be 3-30 letters, digits, '.', '_' or '-' and start with a letter or digit"
+	case "password":
+		return "must be at least 8 characters and contain upper and lower case letters and a digit"
+	case "roleid":
+		return "does not refer to an existing role"
+	case "http_url":
+		return "must be an http or https URL"
+	case "hexcolor":
+		return "must be a hex color such as #1a2b3c"
+	case "timezone":
+		return "must be an IANA timezone such as Europe/Tirane"
+	case "bcp47_language_tag":
+		return "must be a language tag such as en or sq-AL"
+	}
+	return "is invalid"
+}
+
+func isStrongPassword(pw string) bool {
+	if len(pw) < 8 {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type customRules struct {
+	Slug     string `json:"slug" validate:"omitempty,slug"`
+	Size     string `json:"size" validate:"omitempty,orgsize"`
+	Username string `json:"username" validate:"omitempty,username"`
+	Password string `json:"password" validate:"omitempty,password"`
+}
+
+func TestValidatorCustomRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     customRules
+		invalid string // the failing field, or "" if valid
+	}{
+		{"empty", customRules{}, ""},
+		{"slug", customRules{Slug: "acme-labs-2"}, ""},
+		{"slug uppercase", customRules{Slug: "Acme"}, "slug"},
+		{"slug double hyphen", customRules{Slug: "acme--labs"}, "slug"},
+		{"slug leading hyphen", customRules{Slug: "-acme"}, "slug"},
+		{"slug trailing hyphen", customRules{Slug: "acme-"}, "slug"},
+		{"size", customRules{Size: "11-50"}, ""},
+		{"size open ended", customRules{Size: "1000+"}, ""},
+		{"size unknown", customRules{Size: "2-9"}, "size"},
+		{"username", customRules{Username: "ada.lovelace_1"}, ""},
+		{"username too short", customRules{Username: "ab"}, "username"},
+		{"username too long", customRules{Username: "a123456789012345678901234567890"}, "username"},
+		{"username leading dot", customRules{Username: ".ada"}, "username"},
+		{"username space", customRules{Username: "ada l"}, "username"},
+		{"password", customRules{Password: "Secret123"}, ""},
+		{"password too short", customRules{Password: "Sec123"}, "password"},
+		{"password no upper", customRules{Password: "secret123"}, "password"},
+		{"password no lower", customRules{Password: "SECRET123"}, "password"},
+		{"password no digit", customRules{Password: "SecretSecret"}, "password"},
+	}
+
+	v := NewValidator(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(context.Background(), &tt.req)
+			if tt.invalid == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			var verr *Error
+			if !errors.As(err, &verr) || verr.Kind != KindValidation {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+			if len(verr.Fields) != 1 || verr.Fields[0].Field != tt.invalid {
+				t.Fatalf("got fields %+v, want only %s", verr.Fields, tt.invalid)
+			}
+		})
+	}
+}
+
+type roleRequest struct {
+	RoleID int `json:"roleId" validate:"required,roleid"`
+}
+
+func TestValidatorRoleID(t *testing.T) {
+	t.Run("unknown role", func(t *testing.T) {
+		// A dry run counts no rows, as for a role that does not exist
+		v := NewValidator(openDB(t, true))
+		err := v.Struct(context.Background(), &roleRequest{RoleID: 7})
+		var verr *Error
+		if !errors.As(err, &verr) || verr.Kind != KindValidation || verr.Fields[0].Field != "roleId" {
+			t.Fatalf("got %v, want roleId to be invalid", err)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		v := NewValidator(openDB(t, false))
+		err := v.Struct(context.Background(), &roleRequest{RoleID: 7})
+		var verr *Error
+		if err == nil || errors.As(err, &verr) {
+			t.Fatalf("got %v, want the database error rather than a validation error", err)
+		}
+	})
+}
+
+// openDB returns a DB whose queries either run dry or fail to connect.
+func openDB(t *testing.T, dryRun bool) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN: "host=127.0.0.1 port=1 user=nobody dbname=none sslmode=disable connect_timeout=1",
+	}), &gorm.Config{DryRun: dryRun, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
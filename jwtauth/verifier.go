@@ -0,0 +1,264 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"org-service/config"
+	"org-service/helper"
+	"org-service/logging"
+)
+
+// An unknown kid triggers at most one JWKS fetch per this interval, so
+// tokens with made-up kids cannot hammer the key server.
+const minJWKSFetchInterval = time.Minute
+
+// Verifier validates user access tokens: the algorithm must be allowed, the
+// key is chosen by kid, and exp, iss and aud are required.
+type Verifier struct {
+	cfg      config.JWTConfig
+	secret   []byte
+	hmacKeys map[string][]byte
+	methods  []string
+	client   *http.Client
+	logger   *slog.Logger
+	now      func() time.Time
+
+	// impersonationKey verifies tokens with kid ImpersonationKID; empty
+	// unless AcceptImpersonation was called.
+	impersonationKey []byte
+
+	mu        sync.RWMutex
+	jwks      map[string]interface{}
+	lastFetch time.Time
+	fetchMu   sync.Mutex
+}
+
+// NewVerifier loads the JWKS file or fetches the JWKS URL, if configured,
+// and fails if the keys cannot be read.
+func NewVerifier(ctx context.Context, cfg config.JWTConfig, logger *slog.Logger) (*Verifier, error) {
+	v := &Verifier{
+		cfg:      cfg,
+		secret:   []byte(cfg.Secret),
+		hmacKeys: make(map[string][]byte, len(cfg.Keys)),
+		methods:  cfg.Algorithms,
+		client:   &http.Client{},
+		logger:   logger,
+		now:      time.Now,
+	}
+	for kid, secret := range cfg.Keys {
+		v.hmacKeys[kid] = []byte(secret)
+	}
+
+	switch {
+	case cfg.JWKSFile != "":
+		keys, err := readJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = keys
+	case cfg.JWKSURL != "":
+		if err := v.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// AcceptImpersonation makes Verify accept tokens issued by imp, whatever
+// JWTConfig.Algorithms allows. It must be called before serving requests.
+func (v *Verifier) AcceptImpersonation(imp *Impersonator) {
+	if !imp.Enabled() {
+		return
+	}
+	v.impersonationKey = imp.key
+	if !slices.Contains(v.methods, jwt.SigningMethodHS256.Alg()) {
+		v.methods = append(slices.Clone(v.methods), jwt.SigningMethodHS256.Alg())
+	}
+}
+
+// RefreshJWKS re-fetches the JWKS URL every JWKSRefresh until ctx is
+// cancelled, keeping the previous keys when a fetch fails. Run it with
+// helper.Background.Go.
+func (v *Verifier) RefreshJWKS(ctx context.Context) {
+	if v.cfg.JWKSURL == "" {
+		return
+	}
+	ticker := time.NewTicker(v.cfg.JWKSRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.fetch(ctx); err != nil {
+				v.logger.Warn("jwks refresh failed, keeping previous keys", "error", err)
+			}
+		}
+	}
+}
+
+// Verify checks tokenString and returns its claims. Errors are unauthorized
+// *helper.Error values with code token_malformed, token_expired or
+// invalid_token. An actorId claim is only accepted on impersonation tokens,
+// and impersonation tokens must carry one.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	// Claims are checked below, with leeway and exp/iss/aud required.
+	parser := jwt.NewParser(jwt.WithValidMethods(v.methods), jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, claims, v.keyFunc(ctx))
+	if err != nil {
+		logging.FromContext(ctx).Debug("token rejected", "error", err)
+		if errors.Is(err, jwt.ErrTokenMalformed) {
+			return nil, helper.Unauthorized("token_malformed", "Malformed token")
+		}
+		return nil, helper.Unauthorized("invalid_token", "Invalid token")
+	}
+
+	now := v.now()
+	if _, ok := claims["exp"]; !ok {
+		return nil, helper.Unauthorized("invalid_token", "Token has no expiry")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-v.cfg.Leeway).Unix(), true) {
+		return nil, helper.Unauthorized("token_expired", "Token has expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(v.cfg.Leeway).Unix(), false) {
+		return nil, helper.Unauthorized("invalid_token", "Token is not valid yet")
+	}
+	if !claims.VerifyIssuer(v.cfg.Issuer, true) || !claims.VerifyAudience(v.cfg.Audience, true) {
+		return nil, helper.Unauthorized("invalid_token", "Invalid token")
+	}
+
+	_, hasActor := claims["actorId"]
+	if hasActor != (token.Header["kid"] == ImpersonationKID) {
+		return nil, helper.Unauthorized("invalid_token", "Invalid token")
+	}
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		if kid == ImpersonationKID {
+			if len(v.impersonationKey) == 0 || token.Method != jwt.SigningMethodHS256 {
+				return nil, errors.New("impersonation tokens are not accepted")
+			}
+			return v.impersonationKey, nil
+		}
+		// HS256 may be allowed only for impersonation tokens.
+		if !slices.Contains(v.cfg.Algorithms, token.Method.Alg()) {
+			return nil, fmt.Errorf("signing method %s is not allowed", token.Method.Alg())
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if kid == "" {
+				if len(v.secret) == 0 {
+					return nil, errors.New("token has no kid")
+				}
+				return v.secret, nil
+			}
+			if key, ok := v.hmacKeys[kid]; ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("unknown kid %q", kid)
+
+		case *jwt.SigningMethodRSA:
+			key, err := v.publicKey(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if rsaKey, ok := key.(*rsa.PublicKey); ok {
+				return rsaKey, nil
+			}
+			return nil, fmt.Errorf("kid %q is not an RSA key", kid)
+
+		case *jwt.SigningMethodEd25519:
+			key, err := v.publicKey(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if edKey, ok := key.(ed25519.PublicKey); ok {
+				return edKey, nil
+			}
+			return nil, fmt.Errorf("kid %q is not an Ed25519 key", kid)
+		}
+		return nil, fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+	}
+}
+
+// publicKey looks kid up in the JWKS, re-fetching the URL once if the kid is
+// unknown, since the issuer may have rotated keys since the last refresh.
+func (v *Verifier) publicKey(ctx context.Context, kid string) (interface{}, error) {
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	v.mu.RLock()
+	key, ok := v.jwks[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if v.cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if err := v.fetchIfStale(ctx); err != nil {
+		v.logger.Warn("jwks fetch for unknown kid failed", "kid", kid, "error", err)
+	}
+
+	v.mu.RLock()
+	key, ok = v.jwks[kid]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	return key, nil
+}
+
+func (v *Verifier) fetch(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.fetchLocked(ctx)
+}
+
+// fetchIfStale fetches unless another fetch finished within
+// minJWKSFetchInterval; concurrent callers wait for one fetch.
+func (v *Verifier) fetchIfStale(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+
+	v.mu.RLock()
+	fresh := v.now().Sub(v.lastFetch) < minJWKSFetchInterval
+	v.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+	return v.fetchLocked(ctx)
+}
+
+// fetchLocked is called with fetchMu held.
+func (v *Verifier) fetchLocked(ctx context.Context) error {
+	keys, err := fetchJWKS(ctx, v.client, v.cfg.JWKSURL)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lastFetch = v.now()
+	if err != nil {
+		return err
+	}
+	v.jwks = keys
+	return nil
+}
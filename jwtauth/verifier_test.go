@@ -0,0 +1,293 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"org-service/config"
+	"org-service/helper"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "org-service"
+	testSecret   = "default-secret-default-secret-00"
+	testKidKey   = "kid-secret-kid-secret-kid-secret"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	ed  ed25519.PrivateKey
+	rsa *rsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{ed: edKey, rsa: rsaKey}
+}
+
+// jwksJSON publishes the Ed25519 key as kid "ed1" and the RSA key as "rsa1".
+func (k testKeys) jwksJSON(t *testing.T) []byte {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	data, err := json.Marshal(jwks{Keys: []jwk{
+		{Kty: "OKP", Crv: "Ed25519", Kid: "ed1", Use: "sig", X: b64(k.ed.Public().(ed25519.PublicKey))},
+		{Kty: "RSA", Kid: "rsa1", N: b64(k.rsa.N.Bytes()), E: b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testConfig() config.JWTConfig {
+	return config.JWTConfig{
+		Secret:     testSecret,
+		Keys:       map[string]string{"hk1": testKidKey},
+		Algorithms: []string{"HS256", "RS256", "EdDSA"},
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		Leeway:     30 * time.Second,
+	}
+}
+
+func newTestVerifier(t *testing.T, cfg config.JWTConfig) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"userId": 7,
+		"iss":    testIssuer,
+		"aud":    testAudience,
+		"iat":    testNow.Unix(),
+		"exp":    testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, keys.jwksJSON(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.JWKSFile = jwksFile
+	v := newTestVerifier(t, cfg)
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := validClaims()
+		for k, value := range changes {
+			if value == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = value
+			}
+		}
+		return claims
+	}
+	hs256 := func(kid string, claims jwt.MapClaims, secret string) func(*testing.T) string {
+		return func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, kid, claims, []byte(secret)) }
+	}
+
+	tests := []struct {
+		name  string
+		token func(*testing.T) string
+		code  string // expected error code, or "" if accepted
+	}{
+		// Key selection by kid
+		{"hmac without kid uses the secret", hs256("", validClaims(), testSecret), ""},
+		{"hmac kid selects its key", hs256("hk1", validClaims(), testKidKey), ""},
+		{"hmac kid with the default secret", hs256("hk1", validClaims(), testSecret), "invalid_token"},
+		{"unknown hmac kid", hs256("hk2", validClaims(), testKidKey), "invalid_token"},
+		{"ed25519 kid", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodEdDSA, "ed1", validClaims(), keys.ed)
+		}, ""},
+		{"rsa kid", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodRS256, "rsa1", validClaims(), keys.rsa)
+		}, ""},
+		{"ed25519 without kid", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodEdDSA, "", validClaims(), keys.ed)
+		}, "invalid_token"},
+		{"unknown jwks kid", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodEdDSA, "ed2", validClaims(), keys.ed)
+		}, "invalid_token"},
+
+		// Algorithm pinning
+		{"algorithm not allowed", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodHS384, "", validClaims(), []byte(testSecret))
+		}, "invalid_token"},
+		{"alg none", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodNone, "", validClaims(), jwt.UnsafeAllowNoneSignatureType)
+		}, "invalid_token"},
+		{"rsa kid with the ed25519 algorithm", func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodEdDSA, "rsa1", validClaims(), keys.ed)
+		}, "invalid_token"},
+		{"hmac signed with the public rsa key", func(t *testing.T) string {
+			pub, _ := json.Marshal(keys.rsa.PublicKey)
+			return sign(t, jwt.SigningMethodHS256, "rsa1", validClaims(), pub)
+		}, "invalid_token"},
+
+		// Issuer and audience
+		{"wrong issuer", hs256("", with(jwt.MapClaims{"iss": "https://evil.example.com"}), testSecret), "invalid_token"},
+		{"no issuer", hs256("", with(jwt.MapClaims{"iss": nil}), testSecret), "invalid_token"},
+		{"wrong audience", hs256("", with(jwt.MapClaims{"aud": "other-service"}), testSecret), "invalid_token"},
+		{"no audience", hs256("", with(jwt.MapClaims{"aud": nil}), testSecret), "invalid_token"},
+		{"audience list", hs256("", with(jwt.MapClaims{"aud": []string{"other-service", testAudience}}), testSecret), ""},
+
+		// Expiry
+		{"expired", hs256("", with(jwt.MapClaims{"exp": testNow.Add(-time.Minute).Unix()}), testSecret), "token_expired"},
+		{"expired within leeway", hs256("", with(jwt.MapClaims{"exp": testNow.Add(-10 * time.Second).Unix()}), testSecret), ""},
+		{"no expiry", hs256("", with(jwt.MapClaims{"exp": nil}), testSecret), "invalid_token"},
+		{"not valid yet", hs256("", with(jwt.MapClaims{"nbf": testNow.Add(time.Minute).Unix()}), testSecret), "invalid_token"},
+
+		// Malformed and tampered tokens
+		{"not a jwt", func(*testing.T) string { return "not-a-token" }, "token_malformed"},
+		{"bad base64", func(*testing.T) string { return "a.b.c" }, "token_malformed"},
+		{"bad signature", func(t *testing.T) string {
+			return hs256("", validClaims(), testSecret)(t) + "x"
+		}, "invalid_token"},
+
+		{"actor claim outside impersonation", hs256("", with(jwt.MapClaims{"actorId": 1}), testSecret), "invalid_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token(t))
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("got %v, want the token accepted", err)
+				}
+				if claims["userId"] != float64(7) {
+					t.Fatalf("got claims %v", claims)
+				}
+				return
+			}
+			if !helper.HasCode(err, tt.code) {
+				t.Fatalf("got %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestVerifyImpersonation(t *testing.T) {
+	cfg := testConfig()
+	cfg.Algorithms = []string{"EdDSA"}
+	v := newTestVerifier(t, cfg)
+	imp := NewImpersonator(cfg, config.ImpersonationConfig{SigningKey: "impersonation-key-impersonation!", TTL: time.Minute})
+	imp.now = func() time.Time { return testNow }
+
+	issued, err := imp.Issue(7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(context.Background(), issued.Token); !helper.HasCode(err, "invalid_token") {
+		t.Fatalf("before AcceptImpersonation: got %v, want invalid_token", err)
+	}
+
+	v.AcceptImpersonation(imp)
+	claims, err := v.Verify(context.Background(), issued.Token)
+	if err != nil {
+		t.Fatalf("got %v, want the token accepted", err)
+	}
+	if claims["actorId"] != float64(1) || claims["userId"] != float64(7) {
+		t.Fatalf("got claims %v", claims)
+	}
+
+	// Accepting impersonation allows HS256 for impersonation tokens only
+	plain := sign(t, jwt.SigningMethodHS256, "", validClaims(), []byte(testSecret))
+	if _, err := v.Verify(context.Background(), plain); !helper.HasCode(err, "invalid_token") {
+		t.Fatalf("plain HS256 token: got %v, want invalid_token", err)
+	}
+	noActor := sign(t, jwt.SigningMethodHS256, ImpersonationKID, validClaims(), imp.key)
+	if _, err := v.Verify(context.Background(), noActor); !helper.HasCode(err, "invalid_token") {
+		t.Fatalf("impersonation token without actorId: got %v, want invalid_token", err)
+	}
+}
+
+// An unknown kid re-fetches the JWKS URL, at most once per
+// minJWKSFetchInterval.
+func TestVerifyFetchesRotatedKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	var published atomic.Value
+	published.Store([]byte(`{"keys":[]}`))
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(published.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.JWKSURL = srv.URL
+	v := newTestVerifier(t, cfg)
+	now := testNow
+	v.now = func() time.Time { return now }
+	v.lastFetch = now
+	token := sign(t, jwt.SigningMethodEdDSA, "ed1", validClaims(), keys.ed)
+
+	// The key is published after the startup fetch; a re-fetch for the
+	// unknown kid within the interval is skipped.
+	published.Store(keys.jwksJSON(t))
+	if _, err := v.Verify(context.Background(), token); !helper.HasCode(err, "invalid_token") {
+		t.Fatalf("got %v, want invalid_token", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("%d fetches, want only the startup fetch", n)
+	}
+
+	now = now.Add(minJWKSFetchInterval)
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatalf("after rotation: got %v, want the token accepted", err)
+	}
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("%d fetches, want 2", n)
+	}
+}
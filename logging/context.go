@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const localsLogger = "logger"
+
+type ctxKey struct{}
+
+// WithContext stores logger in ctx.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithContext, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// SetCtx makes logger the request logger for c.
+func SetCtx(c *fiber.Ctx, logger *slog.Logger) {
+	c.Locals(localsLogger, logger)
+	c.SetUserContext(WithContext(c.UserContext(), logger))
+}
+
+// FromCtx returns the request logger (carrying request_id and, once known,
+// user_id and org_id), or slog.Default() outside a request.
+func FromCtx(c *fiber.Ctx) *slog.Logger {
+	if logger, ok := c.Locals(localsLogger).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// AddCtxAttrs adds fields to every later log line of the request.
+func AddCtxAttrs(c *fiber.Ctx, args ...any) {
+	SetCtx(c, FromCtx(c).With(args...))
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailRegex  = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	jwtRegex    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerRegex = regexp.MustCompile(`(?i)(bearer|apikey)\s+\S+`)
+	apiKeyRegex = regexp.MustCompile(`(osk_[0-9a-f]{8})_[A-Za-z0-9_\-]+`)
+
+	// Attribute keys (lower-cased, substring match) whose values are dropped.
+	secretKeys = []string{"password", "token", "secret", "authorization", "cookie", "apikey", "api_key", "recovery"}
+)
+
+// redactHandler scrubs secrets and PII from log records before passing them
+// to the wrapped handler. Values under secret-looking keys are replaced
+// entirely; emails and bearer tokens inside any string are masked.
+type redactHandler struct {
+	next slog.Handler
+}
+
+func NewRedactHandler(next slog.Handler) slog.Handler {
+	return &redactHandler{next: next}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, RedactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	key := strings.ToLower(a.Key)
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		clean := make([]any, len(group))
+		for i, ga := range group {
+			clean[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+		if s, ok := a.Value.Any().(interface{ String() string }); ok {
+			return slog.String(a.Key, RedactString(s.String()))
+		}
+	}
+	return a
+}
+
+// RedactString masks emails (j***@example.com), JWTs, bearer/API key
+// credentials and raw API keys (keeping their prefix) inside s.
+func RedactString(s string) string {
+	if s == "" {
+		return s
+	}
+	s = jwtRegex.ReplaceAllString(s, redacted)
+	s = bearerRegex.ReplaceAllString(s, "$1 "+redacted)
+	s = apiKeyRegex.ReplaceAllString(s, "${1}_"+redacted)
+	s = emailRegex.ReplaceAllString(s, "$1***@$2")
+	return s
+}
@@ -1,73 +1,250 @@
 package main
 
 import (
+	"context"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "org-service/docs"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/middleware/basicauth"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/swagger"
 	"gopkg.in/gomail.v2"
+	"gorm.io/gorm"
 
+	"org-service/admin"
+	"org-service/apikeys"
+	"org-service/config"
 	"org-service/db"
+	"org-service/health"
+	"org-service/helper"
+	"org-service/jwtauth"
+	"org-service/logging"
+	"org-service/metrics"
+	"org-service/mfa"
 	"org-service/middleware"
 	orgsvc "org-service/org"
+	"org-service/ratelimit"
+	"org-service/storage"
+	"org-service/teams"
+	"org-service/tracing"
 	usersvc "org-service/users"
 )
 
 func main() {
-	uiAppUrl := ""
-	if os.Getenv("UI_APP_URL") != "" {
-		uiAppUrl = os.Getenv("UI_APP_URL")
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Fatal(slog.Default(), "failed to load configuration", "error", err)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		logging.Fatal(slog.Default(), "failed to create logger", "error", err)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.Env)
+	if err != nil {
+		logging.Fatal(logger, "failed to set up tracing", "error", err)
+	}
+
+	db, err := db.ConnectDB(cfg.DB, logger)
+	if err != nil {
+		logging.Fatal(logger, "failed to connect to database", "error", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(db, os.Args[2:])
+		closeDB(db)
+		if err != nil {
+			logging.Fatal(logger, "migrate failed", "error", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "platform-admin" {
+		err := runPlatformAdmin(db, os.Args[2:])
+		closeDB(db)
+		if err != nil {
+			logging.Fatal(logger, "platform-admin failed", "error", err)
+		}
+		return
 	}
+
+	workers := helper.NewBackground()
+
 	app := fiber.New(fiber.Config{
-		BodyLimit: 10 * 1024 * 1024, // 10 MB
+		BodyLimit:    10 * 1024 * 1024, // 10 MB
+		ErrorHandler: middleware.ErrorHandler,
+		// c.IP() honours ProxyHeader only on requests from TrustedProxies
+		ProxyHeader:             cfg.Proxy.Header,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Proxy.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
+	app.Use(middleware.RequestLogger(logger))
+	app.Use(tracing.HTTPMiddleware(helper.HTTPStatus))
+	app.Use(metrics.HTTPMiddleware())
+
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Change this to specific domains in production
-		AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  strings.Join(cfg.CORS.AllowOrigins, ","),
+		AllowMethods:  "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, traceparent, tracestate, " + middleware.HeaderRequestID,
+		ExposeHeaders: middleware.HeaderRequestID + ", " + fiber.HeaderRetryAfter,
 	}))
 
-	db, err := db.ConnectDB()
-	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
-	}	
+	// Pass gomail dialer to user service
+	dialer := gomail.NewDialer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password)
+
+	healthz := health.New(
+		health.DBCheck(db),
+		health.SMTPCheck(cfg.Mail.Host, cfg.Mail.Port),
+	)
+	app.Get("/healthz", healthz.Liveness)
+	app.Get("/readyz", healthz.Readiness)
 
+	if err := metrics.RegisterDB(db); err != nil {
+		logging.Fatal(logger, "failed to register database metrics", "error", err)
+	}
+	if err := tracing.RegisterDB(db); err != nil {
+		logging.Fatal(logger, "failed to register database tracing", "error", err)
+	}
 
-	defaultLogger := log.DefaultLogger()
+	verifier, err := jwtauth.NewVerifier(context.Background(), cfg.JWT, logger)
+	if err != nil {
+		logging.Fatal(logger, "failed to load jwt keys", "error", err)
+	}
+	workers.Go(verifier.RefreshJWKS)
+	impersonator := jwtauth.NewImpersonator(cfg.JWT, cfg.Impersonation)
+	verifier.AcceptImpersonation(impersonator)
 
-	authMiddleware := middleware.Authentication(os.Getenv("JWT_SECRET_KEY"))
+	apiKeyApi := apikeys.NewAPIKeyService(db, logger)
+	authMiddleware := middleware.Authentication(verifier, apiKeyApi, db)
 	rbac := middleware.NewRBAC(db)
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "redis" {
+		redisCfg := cfg.RateLimit.Redis
+		limitStore = ratelimit.NewRedisStore(ratelimit.NewRESPClient(redisCfg.Addr, redisCfg.Password, redisCfg.DB), redisCfg.Prefix)
+	}
+	limiter := middleware.NewRateLimiter(limitStore, cfg.RateLimit)
 
 	apisRouter := app.Group("/api")
 	orgRoute := apisRouter.Group("/o/:orgId", authMiddleware, rbac.OrgAccess)
 
 	apisRouter.Get("/swagger/*", basicauth.New(basicauth.Config{
 		Users: map[string]string{
-			"influxo": "123123123",
+			cfg.Swagger.Username: cfg.Swagger.Password,
 		},
 	}), swagger.HandlerDefault)
 
-	// Pass gomail dialer to user service
-	dialer := gomail.NewDialer("smtp.gmail.com", 587, os.Getenv("EMAIL_FROM"), os.Getenv("MAIL_PASSWORD"))
-
+	blobs, err := storage.New(context.Background(), cfg.Storage)
+	if err != nil {
+		logging.Fatal(logger, "failed to set up storage", "error", err)
+	}
+	// Signed URLs of the local backend point back at this service
+	if local, ok := blobs.(*storage.LocalStore); ok {
+		apisRouter.Get("/blobs/*", local.Handler)
+	}
 
 	// Initialize service
-	orgApiSvc := orgsvc.NewOrgHTTPTransport(orgsvc.NewOrgService(db, defaultLogger), defaultLogger)
-	userApiSvc := usersvc.NewUserHTTPTransport(usersvc.NewUserService(db, dialer, uiAppUrl))
-	
+	orgApiSvc := orgsvc.NewOrgHTTPTransport(orgsvc.NewOrgService(db, blobs, cfg.Storage.SignedURLTTL, logger), logger)
+	userApiSvc := usersvc.NewUserHTTPTransport(usersvc.NewUserService(db, dialer, blobs, cfg, logger), logger)
+	apiKeySvc := apikeys.NewAPIKeyHTTPTransport(apiKeyApi, logger)
+	teamSvc := teams.NewTeamHTTPTransport(teams.NewTeamService(db, cfg.Teams, logger), logger)
+	adminSvc := admin.NewAdminHTTPTransport(admin.NewAdminService(db, impersonator, logger), logger)
+	mfaApi, err := mfa.NewMFAService(db, cfg.MFA, logger)
+	if err != nil {
+		logging.Fatal(logger, "failed to set up mfa", "error", err)
+	}
+	mfaSvc := mfa.NewMFAHTTPTransport(mfaApi, logger)
+
 	// Register routes
-	orgsvc.RegisterRoutes(apisRouter, orgRoute, orgApiSvc, authMiddleware)
-	usersvc.RegisterRoutes(apisRouter, orgRoute, userApiSvc, authMiddleware)
-	
-	db.AutoMigrate(
-		&orgsvc.Org{},
-		&orgsvc.UserOrgRole{},
-	)
-		app.Listen(":3002")
-}
\ No newline at end of file
+	orgsvc.RegisterRoutes(apisRouter, orgRoute, orgApiSvc, authMiddleware, rbac)
+	usersvc.RegisterRoutes(apisRouter, orgRoute, userApiSvc, authMiddleware, limiter, rbac)
+	apikeys.RegisterRoutes(orgRoute, apiKeySvc, rbac)
+	teams.RegisterRoutes(orgRoute, teamSvc, rbac)
+	admin.RegisterRoutes(apisRouter, adminSvc, authMiddleware, rbac)
+	mfa.RegisterRoutes(apisRouter, mfaSvc, authMiddleware, limiter)
+
+	if err := warnPendingMigrations(db, logger); err != nil {
+		logger.Warn("could not check schema version", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 2)
+	go func() {
+		listenErr <- app.Listen(cfg.Addr())
+	}()
+
+	// /metrics lists routes, error codes and pool stats, so it gets its own
+	// listener instead of sitting on the public API.
+	var metricsApp *fiber.App
+	if cfg.MetricsPort != 0 {
+		metricsApp = fiber.New(fiber.Config{DisableStartupMessage: true})
+		metricsApp.Get("/metrics", metrics.Handler())
+		go func() {
+			listenErr <- metricsApp.Listen(cfg.MetricsAddr())
+		}()
+	}
+
+	select {
+	case err := <-listenErr:
+		closeDB(db)
+		logging.Fatal(logger, "server stopped", "error", err)
+	case <-ctx.Done():
+	}
+
+	// Fail readiness and keep serving until load balancers have noticed,
+	// then stop accepting requests, let in-flight ones and background
+	// workers finish within the deadline, release the DB pool and flush
+	// spans. A second signal skips the drain delay.
+	logger.Info("shutting down, waiting for in-flight work", "drain_delay", cfg.ShutdownDrainDelay.String(), "timeout", cfg.ShutdownTimeout.String())
+	healthz.SetShuttingDown()
+	drainCtx, stopDrain := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	stop()
+	select {
+	case <-time.After(cfg.ShutdownDrainDelay):
+	case <-drainCtx.Done():
+	}
+	stopDrain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		logger.Error("http shutdown failed", "error", err)
+	}
+	if metricsApp != nil {
+		if err := metricsApp.ShutdownWithContext(shutdownCtx); err != nil {
+			logger.Error("metrics shutdown failed", "error", err)
+		}
+	}
+	if err := workers.Shutdown(shutdownCtx); err != nil {
+		logger.Error("background workers shutdown failed", "error", err)
+	}
+	closeDB(db)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("flushing traces failed", "error", err)
+	}
+	logger.Info("shutdown complete")
+}
+
+func closeDB(conn *gorm.DB) {
+	sqlDB, err := conn.DB()
+	if err != nil {
+		slog.Error("closing database failed", "error", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("closing database failed", "error", err)
+	}
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+
+	"org-service/helper"
+)
+
+const namespace = "org_service"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "gorm statement latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "outcome"})
+
+	emailsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_sent_total",
+		Help:      "Emails handed to the mail server, by template.",
+	}, []string{"template"})
+
+	emailsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_failed_total",
+		Help:      "Emails that could not be sent, by template.",
+	}, []string{"template"})
+
+	invitationsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invitations_created_total",
+		Help:      "Invitations created.",
+	})
+
+	invitationsAccepted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invitations_accepted_total",
+		Help:      "Invitations accepted.",
+	})
+
+	rbacDenials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rbac_denials_total",
+		Help:      "Requests rejected by RBAC, by reason.",
+	}, []string{"reason"})
+
+	rateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected with 429, by route and bucket (ip, user, org, lockout).",
+	}, []string{"route", "bucket"})
+)
+
+// Handler serves the default Prometheus registry.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// HTTPMiddleware records latency per route pattern (e.g. /api/o/:orgId/members)
+// so that path parameters do not explode label cardinality. Errors are
+// passed on for RequestLogger to render; their status comes from
+// helper.HTTPStatus.
+func HTTPMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = helper.HTTPStatus(err)
+		}
+
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+
+		httpRequestDuration.
+			WithLabelValues(c.Method(), route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// RegisterDB adds gorm callbacks that time every statement and exports the
+// connection pool stats of db's sql.DB.
+func RegisterDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, "postgres")); err != nil {
+		return err
+	}
+
+	const startKey = "metrics:start"
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(startKey, time.Now())
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, ok := tx.InstanceGet(startKey)
+			if !ok {
+				return
+			}
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+			outcome := "ok"
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				outcome = "error"
+			}
+			table := tx.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+			dbQueryDuration.WithLabelValues(operation, table, outcome).Observe(time.Since(start).Seconds())
+		}
+	}
+
+	cb := db.Callback()
+	registrations := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range registrations {
+		if err := r.before("metrics:before_"+r.operation, before); err != nil {
+			return err
+		}
+		if err := r.after("metrics:after_"+r.operation, after(r.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func EmailSent(template string) {
+	emailsSent.WithLabelValues(template).Inc()
+}
+
+func EmailFailed(template string) {
+	emailsFailed.WithLabelValues(template).Inc()
+}
+
+func InvitationCreated() {
+	invitationsCreated.Inc()
+}
+
+func InvitationAccepted() {
+	invitationsAccepted.Inc()
+}
+
+func RBACDenied(reason string) {
+	rbacDenials.WithLabelValues(reason).Inc()
+}
+
+func RateLimited(route, bucket string) {
+	rateLimitRejections.WithLabelValues(route, bucket).Inc()
+}
@@ -0,0 +1,55 @@
+package mfa
+
+import "time"
+
+// UserRequest identifies the current user and the access token in use.
+type UserRequest struct {
+	CurrentUserID int       `json:"-" validate:"required"`
+	TokenHash     string    `json:"-"`
+	TokenExpires  time.Time `json:"-"`
+}
+
+// CodeRequest carries a code from the authenticator app.
+type CodeRequest struct {
+	UserRequest
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// VerifyRequest carries either a code from the authenticator app or an
+// unused recovery code.
+type VerifyRequest struct {
+	UserRequest
+	Code         string `json:"code" validate:"omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recoveryCode" validate:"omitempty,max=20"`
+}
+
+type MFAStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt"`
+	RecoveryCodesRemaining int64      `json:"recoveryCodesRemaining"`
+	// Verified is whether the access token in use has passed MFA.
+	Verified bool `json:"verified"`
+}
+
+// EnrollmentResponse is shown once: the secret is for manual entry,
+// provisioningUri for the QR code.
+type EnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// RecoveryCodesResponse is the only time the codes are shown.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type VerificationResponse struct {
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// RecoveryCodesRemaining is set when a recovery code was used.
+	RecoveryCodesRemaining *int64 `json:"recoveryCodesRemaining,omitempty"`
+}
+
+type StatusResponse struct {
+	Status bool `json:"status"`
+}
@@ -0,0 +1,46 @@
+package mfa
+
+import (
+	"org-service/helper"
+	"org-service/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route name for the limits in config.RateLimitConfig.Routes.
+const rateLimitVerify = "mfa_verify"
+
+// RegisterRoutes mounts the current user's MFA settings. Everything but the
+// status is the account holder's alone, so impersonating admins are turned
+// away. Every route that takes a code shares one lockout.
+func RegisterRoutes(router fiber.Router, mfaHttpTransport MFAHTTPTransport, authMiddleware func(c *fiber.Ctx) error, limiter middleware.RateLimiter) {
+	mfaRouter := router.Group("/users/me/mfa", authMiddleware)
+	mfaRouter.Get("/", mfaHttpTransport.GetStatus)
+	mfaRouter.Post("/totp", middleware.NotWhileImpersonating, mfaHttpTransport.StartEnrollment)
+
+	mfaRouter.Post("/totp/confirm",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitVerify),
+		limiter.Lockout(rateLimitVerify, middleware.ByUser, isInvalidCode),
+		mfaHttpTransport.ConfirmEnrollment)
+	mfaRouter.Post("/verify",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitVerify),
+		limiter.Lockout(rateLimitVerify, middleware.ByUser, isInvalidCode),
+		mfaHttpTransport.Verify)
+	mfaRouter.Post("/recovery-codes",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitVerify),
+		limiter.Lockout(rateLimitVerify, middleware.ByUser, isInvalidCode),
+		mfaHttpTransport.RegenerateRecoveryCodes)
+	mfaRouter.Post("/disable",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitVerify),
+		limiter.Lockout(rateLimitVerify, middleware.ByUser, isInvalidCode),
+		mfaHttpTransport.Disable)
+}
+
+// isInvalidCode counts wrong or reused codes towards the lockout.
+func isInvalidCode(err error) bool {
+	return helper.HasCode(err, "invalid_mfa_code")
+}
@@ -0,0 +1,55 @@
+package mfa
+
+import "time"
+
+const (
+	UserMFATableName      = "user_mfa"
+	RecoveryCodeTableName = "mfa_recovery_codes"
+	VerificationTableName = "mfa_verifications"
+)
+
+// Ways a user can pass MFA, recorded on the verification.
+const (
+	MethodTOTP         = "totp"
+	MethodRecoveryCode = "recovery_code"
+)
+
+// UserMFA is a user's TOTP enrollment. It is pending until EnabledAt is set
+// by confirming a code.
+type UserMFA struct {
+	UserID       int    `gorm:"primaryKey;autoIncrement:false"`
+	TOTPSecret   string `gorm:"column:totp_secret;not null"`
+	EnabledAt    *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (UserMFA) TableName() string {
+	return UserMFATableName
+}
+
+type RecoveryCode struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"not null"`
+	CodeHash  string `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (RecoveryCode) TableName() string {
+	return RecoveryCodeTableName
+}
+
+// Verification records that the access token with TokenHash passed MFA. It
+// lapses with the token.
+type Verification struct {
+	TokenHash  string `gorm:"primaryKey"`
+	UserID     int    `gorm:"not null"`
+	Method     string `gorm:"not null"`
+	VerifiedAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (Verification) TableName() string {
+	return VerificationTableName
+}
@@ -0,0 +1,471 @@
+// Package mfa adds TOTP multi-factor authentication as a step-up check.
+// Users sign in at the identity provider that issues the access tokens, so
+// this service never sees a login. Instead, after signing in, the client
+// sends a code to POST /api/users/me/mfa/verify, which marks that access
+// token as verified until it expires. A new token needs a new code.
+//
+// Tokens are accepted whether or not they are verified. Only the routes
+// wrapped in middleware.RequireMFA check, and only for owners and admins of
+// orgs with requireMfaForAdmins set.
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"org-service/audit"
+	"org-service/config"
+	"org-service/helper"
+	orgsvc "org-service/org"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Audit actions recorded by the MFA service. They belong to no org.
+const (
+	AuditActionEnabled                = "mfa.enabled"
+	AuditActionDisabled               = "mfa.disabled"
+	AuditActionRecoveryCodesGenerated = "mfa.recovery_codes.generated"
+	AuditActionRecoveryCodeUsed       = "mfa.recovery_code.used"
+)
+
+type mfaApi struct {
+	db       *gorm.DB
+	box      *secretBox
+	issuer   string
+	logger   *slog.Logger
+	validate *helper.Validator
+	now      func() time.Time
+}
+
+type MFAAPI interface {
+	GetStatus(ctx context.Context, req *UserRequest) (*MFAStatusResponse, error)
+	StartEnrollment(ctx context.Context, req *UserRequest) (*EnrollmentResponse, error)
+	ConfirmEnrollment(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error)
+	Verify(ctx context.Context, req *VerifyRequest) (*VerificationResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error)
+	Disable(ctx context.Context, req *VerifyRequest) (*StatusResponse, error)
+}
+
+func NewMFAService(db *gorm.DB, cfg config.MFAConfig, logger *slog.Logger) (MFAAPI, error) {
+	box, err := newSecretBox(cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up mfa secret encryption: %w", err)
+	}
+	if cfg.SecretKey == "" {
+		logger.Warn("MFA_SECRET_KEY is not set, TOTP secrets are stored unencrypted")
+	}
+	return &mfaApi{db: db, box: box, issuer: cfg.Issuer, logger: logger, validate: helper.NewValidator(db), now: time.Now}, nil
+}
+
+// @Summary      	GetMFAStatus
+// @Description		Returns whether the current user has MFA enabled, how many recovery codes are left and whether the access token in use has passed MFA.
+// @Tags			MFA
+// @Produce			json
+// @Param			Authorization			header		string				true	"Authorization Key(e.g Bearer key)"
+// @Success			200						{object}	MFAStatusResponse
+// @Router			/api/users/me/mfa		[GET]
+func (s *mfaApi) GetStatus(ctx context.Context, req *UserRequest) (*MFAStatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	res := &MFAStatusResponse{}
+	var enrollment UserMFA
+	if err := db.Where("user_id = ? AND enabled_at IS NOT NULL", req.CurrentUserID).Limit(1).Find(&enrollment).Error; err != nil {
+		return nil, err
+	}
+	if enrollment.EnabledAt == nil {
+		return res, nil
+	}
+	res.Enabled, res.EnabledAt = true, enrollment.EnabledAt
+
+	if err := db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", req.CurrentUserID).Count(&res.RecoveryCodesRemaining).Error; err != nil {
+		return nil, err
+	}
+	err := db.Raw("SELECT EXISTS (SELECT 1 FROM "+VerificationTableName+" WHERE token_hash = ? AND user_id = ? AND expires_at > ?)",
+		req.TokenHash, req.CurrentUserID, s.now()).Scan(&res.Verified).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// @Summary      	StartMFAEnrollment
+// @Description		Creates a new TOTP secret for the current user, replacing an unconfirmed one. Show provisioningUri as a QR code, then confirm with a code from the app. Fails if MFA is already enabled.
+// @Tags			MFA
+// @Produce			json
+// @Param			Authorization			header		string				true	"Authorization Key(e.g Bearer key)"
+// @Success			200						{object}	EnrollmentResponse
+// @Router			/api/users/me/mfa/totp	[POST]
+func (s *mfaApi) StartEnrollment(ctx context.Context, req *UserRequest) (*EnrollmentResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+	sealed, err := s.box.seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+
+	var user struct{ Email string }
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(orgsvc.UserTableName).Select("email").Where("id = ?", req.CurrentUserID).Take(&user).Error; err != nil {
+			return helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+		}
+
+		var existing UserMFA
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", req.CurrentUserID).Limit(1).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing.EnabledAt != nil {
+			return helper.Conflict("mfa_already_enabled", "multi-factor authentication is already enabled")
+		}
+
+		enrollment := UserMFA{UserID: req.CurrentUserID, TOTPSecret: sealed, CreatedAt: s.now()}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"totp_secret", "last_used_step", "created_at"}),
+		}).Create(&enrollment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnrollmentResponse{Secret: secret, ProvisioningURI: provisioningURI(s.issuer, user.Email, secret)}, nil
+}
+
+// @Summary      	ConfirmMFAEnrollment
+// @Description		Enables MFA once a code from the authenticator app matches, and returns single-use recovery codes. They are not shown again. The access token in use counts as verified.
+// @Tags			MFA
+// @Accept			json
+// @Produce			json
+// @Param			Authorization				header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			CodeRequest					body		CodeRequest				true	"CodeRequest"
+// @Success			200							{object}	RecoveryCodesResponse
+// @Router			/api/users/me/mfa/totp/confirm	[POST]
+func (s *mfaApi) ConfirmEnrollment(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		enrollment, err := lockEnrollment(tx, req.CurrentUserID)
+		if err != nil {
+			return err
+		}
+		if enrollment == nil {
+			return helper.NotFound("mfa_not_started", "start multi-factor authentication enrollment first")
+		}
+		if enrollment.EnabledAt != nil {
+			return helper.Conflict("mfa_already_enabled", "multi-factor authentication is already enabled")
+		}
+		step, err := s.checkCode(enrollment, req.Code)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		err = tx.Model(&UserMFA{}).Where("user_id = ?", req.CurrentUserID).
+			Updates(map[string]interface{}{"enabled_at": now, "last_used_step": step}).Error
+		if err != nil {
+			return err
+		}
+		if codes, err = replaceRecoveryCodes(tx, req.CurrentUserID); err != nil {
+			return err
+		}
+		if err := s.markVerified(tx, &req.UserRequest, MethodTOTP); err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, AuditActionEnabled, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("mfa enabled", "user_id", req.CurrentUserID)
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// @Summary      	VerifyMFA
+// @Description		Checks a code from the authenticator app, or an unused recovery code, and marks the access token in use as verified until it expires. Call it after each sign-in; tokens are accepted unverified everywhere else. Orgs that require MFA let their owners and admins use privileged routes only with a verified token.
+// @Tags			MFA
+// @Accept			json
+// @Produce			json
+// @Param			Authorization				header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			VerifyRequest				body		VerifyRequest			true	"VerifyRequest"
+// @Success			200							{object}	VerificationResponse
+// @Router			/api/users/me/mfa/verify	[POST]
+func (s *mfaApi) Verify(ctx context.Context, req *VerifyRequest) (*VerificationResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validateVerify(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.TokenHash == "" {
+		return nil, helper.Forbidden("permission_denied", "only access tokens can be verified")
+	}
+
+	res := &VerificationResponse{ExpiresAt: req.TokenExpires}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		method, remaining, err := s.useFactor(tx, req)
+		if err != nil {
+			return err
+		}
+		res.Method = method
+		if method == MethodRecoveryCode {
+			res.RecoveryCodesRemaining = &remaining
+		}
+		return s.markVerified(tx, &req.UserRequest, method)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// @Summary      	RegenerateRecoveryCodes
+// @Description		Replaces all recovery codes of the current user with new ones, confirmed with a code from the authenticator app. The new codes are not shown again.
+// @Tags			MFA
+// @Accept			json
+// @Produce			json
+// @Param			Authorization				header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			CodeRequest					body		CodeRequest				true	"CodeRequest"
+// @Success			200							{object}	RecoveryCodesResponse
+// @Router			/api/users/me/mfa/recovery-codes	[POST]
+func (s *mfaApi) RegenerateRecoveryCodes(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		enrollment, err := lockEnabledEnrollment(tx, req.CurrentUserID)
+		if err != nil {
+			return err
+		}
+		step, err := s.checkCode(enrollment, req.Code)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&UserMFA{}).Where("user_id = ?", req.CurrentUserID).Update("last_used_step", step).Error; err != nil {
+			return err
+		}
+		if codes, err = replaceRecoveryCodes(tx, req.CurrentUserID); err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, AuditActionRecoveryCodesGenerated, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// @Summary      	DisableMFA
+// @Description		Turns MFA off for the current user, confirmed with a code from the authenticator app or a recovery code. Refused while the user is an owner or admin of an org that requires MFA.
+// @Tags			MFA
+// @Accept			json
+// @Produce			json
+// @Param			Authorization				header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			VerifyRequest				body		VerifyRequest			true	"VerifyRequest"
+// @Success			200							{object}	StatusResponse
+// @Router			/api/users/me/mfa/disable	[POST]
+func (s *mfaApi) Disable(ctx context.Context, req *VerifyRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validateVerify(ctx, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var requiringOrgs int64
+		err := tx.Table(orgsvc.UserOrgRoleTableName).
+			Joins("JOIN "+orgsvc.OrgSettingsTableName+" ON "+orgsvc.OrgSettingsTableName+".org_id = user_org_roles.org_id").
+			Where("user_org_roles.user_id = ? AND user_org_roles.role_id IN (1, 2) AND user_org_roles.status = ?", req.CurrentUserID, "active").
+			Where(orgsvc.OrgSettingsTableName + ".require_mfa_for_admins").
+			Count(&requiringOrgs).Error
+		if err != nil {
+			return err
+		}
+		if requiringOrgs > 0 {
+			return helper.Forbidden("mfa_required_by_org", "an org you administer requires multi-factor authentication")
+		}
+
+		if _, _, err := s.useFactor(tx, req); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", req.CurrentUserID).Delete(&UserMFA{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", req.CurrentUserID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", req.CurrentUserID).Delete(&Verification{}).Error; err != nil {
+			return err
+		}
+		return record(tx, req.CurrentUserID, AuditActionDisabled, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("mfa disabled", "user_id", req.CurrentUserID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+func (s *mfaApi) validateVerify(ctx context.Context, req *VerifyRequest) error {
+	req.RecoveryCode = strings.TrimSpace(req.RecoveryCode)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return err
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		return helper.ValidationError("validation_failed", "send either code or recoveryCode",
+			helper.FieldError{Field: "code", Message: "is required without recoveryCode"})
+	}
+	return nil
+}
+
+// useFactor checks the code or recovery code of req against the user's
+// enabled enrollment and uses it up. It returns the method and, for recovery
+// codes, how many are left.
+func (s *mfaApi) useFactor(tx *gorm.DB, req *VerifyRequest) (string, int64, error) {
+	enrollment, err := lockEnabledEnrollment(tx, req.CurrentUserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if req.Code != "" {
+		step, err := s.checkCode(enrollment, req.Code)
+		if err != nil {
+			return "", 0, err
+		}
+		err = tx.Model(&UserMFA{}).Where("user_id = ?", req.CurrentUserID).Update("last_used_step", step).Error
+		return MethodTOTP, 0, err
+	}
+
+	result := tx.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", req.CurrentUserID, hashRecoveryCode(req.RecoveryCode)).
+		Update("used_at", s.now())
+	if result.Error != nil {
+		return "", 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", 0, invalidCode("recoveryCode")
+	}
+	var remaining int64
+	if err := tx.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", req.CurrentUserID).Count(&remaining).Error; err != nil {
+		return "", 0, err
+	}
+	if err := record(tx, req.CurrentUserID, AuditActionRecoveryCodeUsed, map[string]interface{}{"remaining": remaining}); err != nil {
+		return "", 0, err
+	}
+	return MethodRecoveryCode, remaining, nil
+}
+
+// checkCode returns the TOTP step code matches, or invalid_mfa_code.
+func (s *mfaApi) checkCode(enrollment *UserMFA, code string) (int64, error) {
+	secret, err := s.box.open(enrollment.TOTPSecret)
+	if err != nil {
+		return 0, err
+	}
+	step, err := checkTOTP(secret, code, s.now(), enrollment.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	if step == 0 {
+		return 0, invalidCode("code")
+	}
+	return step, nil
+}
+
+// markVerified remembers that the access token of req passed MFA, and drops
+// the user's verifications of tokens that have expired.
+func (s *mfaApi) markVerified(tx *gorm.DB, req *UserRequest, method string) error {
+	if req.TokenHash == "" {
+		return nil
+	}
+	now := s.now()
+	if err := tx.Where("user_id = ? AND expires_at <= ?", req.CurrentUserID, now).Delete(&Verification{}).Error; err != nil {
+		return err
+	}
+	verification := Verification{TokenHash: req.TokenHash, UserID: req.CurrentUserID, Method: method, VerifiedAt: now, ExpiresAt: req.TokenExpires}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"method", "verified_at", "expires_at"}),
+	}).Create(&verification).Error
+}
+
+// lockEnrollment returns the user's enrollment, locked so a code cannot be
+// used twice concurrently, or nil if there is none.
+func lockEnrollment(tx *gorm.DB, userID int) (*UserMFA, error) {
+	var enrollment UserMFA
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&enrollment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+func lockEnabledEnrollment(tx *gorm.DB, userID int) (*UserMFA, error) {
+	enrollment, err := lockEnrollment(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || enrollment.EnabledAt == nil {
+		return nil, helper.Conflict("mfa_not_enabled", "multi-factor authentication is not enabled")
+	}
+	return enrollment, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		rows = append(rows, RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func record(tx *gorm.DB, userID int, action string, details map[string]interface{}) error {
+	return audit.Record(tx, &audit.Event{
+		ActorUserID: &userID,
+		Action:      action,
+		TargetType:  "user",
+		TargetID:    strconv.Itoa(userID),
+		Details:     details,
+	})
+}
+
+// invalidCode is returned for a wrong or reused code. Its code counts
+// towards the lockout.
+func invalidCode(field string) error {
+	return helper.ValidationError("invalid_mfa_code", field+" is incorrect", helper.FieldError{Field: field, Message: "is incorrect"})
+}
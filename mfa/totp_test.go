@@ -0,0 +1,162 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The RFC 6238 appendix B secret, "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC 6238 SHA1 test vectors, cut to 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfcSecret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := totpCode("not base32!", 1); err == nil {
+		t.Error("invalid secret: got no error")
+	}
+}
+
+func TestCheckTOTP(t *testing.T) {
+	// 1111111111 is step 37037037.
+	now := time.Unix(1111111111, 0)
+	const step = 37037037
+	code := func(step int64) string {
+		c, err := totpCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		want         int64
+	}{
+		{"current step", "050471", 0, step},
+		{"surrounding spaces", " 050471 ", 0, step},
+		{"previous step", code(step - 1), 0, step - 1},
+		{"next step", code(step + 1), 0, step + 1},
+		{"two steps old", code(step - 2), 0, 0},
+		{"two steps ahead", code(step + 2), 0, 0},
+		{"wrong code", "000000", 0, 0},
+		{"empty", "", 0, 0},
+		{"replayed", "050471", step, 0},
+		{"older than the last used step", code(step - 1), step, 0},
+		{"newer than the last used step", code(step + 1), step, step + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkTOTP(rfcSecret, tt.code, now, tt.lastUsedStep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got step %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretBox(t *testing.T) {
+	const secret = rfcSecret
+	box, err := newSecretBox("mfa-secret-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := newSecretBox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := newSecretBox("another-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, sealedPrefix) || strings.Contains(sealed, secret) {
+		t.Fatalf("sealed = %q, want it encrypted", sealed)
+	}
+	again, _ := box.seal(secret)
+	if again == sealed {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+
+	unsealed, err := plain.seal(secret)
+	if err != nil || unsealed != secret {
+		t.Fatalf("without a key: got %q, %v, want the secret as is", unsealed, err)
+	}
+
+	tests := []struct {
+		name    string
+		box     *secretBox
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"round trip", box, sealed, secret, false},
+		{"plain secret with a key", box, secret, secret, false},
+		{"plain secret without a key", plain, secret, secret, false},
+		{"sealed secret without a key", plain, sealed, "", true},
+		{"wrong key", other, sealed, "", true},
+		{"corrupt base64", box, sealedPrefix + "!!!", "", true},
+		{"too short", box, sealedPrefix + "AAAA", "", true},
+		{"tampered", box, sealed[:len(sealed)-4] + "AAAA", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.box.open(tt.stored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(hashes), recoveryCodeCount)
+	}
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted xxxxx-xxxxx", code)
+		}
+		for _, typed := range []string{code, strings.ToUpper(code), strings.ReplaceAll(code, "-", " ")} {
+			if hashRecoveryCode(typed) != hashes[i] {
+				t.Errorf("%q does not match the hash of %q", typed, code)
+			}
+		}
+	}
+}
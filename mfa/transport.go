@@ -0,0 +1,136 @@
+package mfa
+
+import (
+	"log/slog"
+	"org-service/helper"
+	"org-service/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MFAHTTPTransport interface {
+	GetStatus(c *fiber.Ctx) error
+	StartEnrollment(c *fiber.Ctx) error
+	ConfirmEnrollment(c *fiber.Ctx) error
+	Verify(c *fiber.Ctx) error
+	RegenerateRecoveryCodes(c *fiber.Ctx) error
+	Disable(c *fiber.Ctx) error
+}
+
+type mfaHTTPTransport struct {
+	mfaApi MFAAPI
+	logger *slog.Logger
+}
+
+func NewMFAHTTPTransport(mfaApi MFAAPI, logger *slog.Logger) MFAHTTPTransport {
+	return &mfaHTTPTransport{mfaApi: mfaApi, logger: logger}
+}
+
+func (s *mfaHTTPTransport) GetStatus(c *fiber.Ctx) error {
+	req := &UserRequest{}
+	if err := userRequest(c, req); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.GetStatus(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *mfaHTTPTransport) StartEnrollment(c *fiber.Ctx) error {
+	req := &UserRequest{}
+	if err := userRequest(c, req); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.StartEnrollment(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *mfaHTTPTransport) ConfirmEnrollment(c *fiber.Ctx) error {
+	req := &CodeRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	if err := userRequest(c, &req.UserRequest); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.ConfirmEnrollment(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *mfaHTTPTransport) Verify(c *fiber.Ctx) error {
+	req := &VerifyRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	if err := userRequest(c, &req.UserRequest); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.Verify(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *mfaHTTPTransport) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	req := &CodeRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	if err := userRequest(c, &req.UserRequest); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.RegenerateRecoveryCodes(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *mfaHTTPTransport) Disable(c *fiber.Ctx) error {
+	req := &VerifyRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	if err := userRequest(c, &req.UserRequest); err != nil {
+		return err
+	}
+
+	resp, err := s.mfaApi.Disable(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+// userRequest fills in the current user and their access token. API keys
+// have no user and are turned away.
+func userRequest(c *fiber.Ctx, req *UserRequest) error {
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+	req.TokenHash = middleware.CtxTokenHash(c)
+	req.TokenExpires = middleware.CtxTokenExpiresAt(c)
+	return nil
+}
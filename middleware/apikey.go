@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Scopes an API key can be granted. Each org route that API keys may call
+// declares one with RBAC.RequireScope; routes without a scope must require a
+// user (CtxUserID), which API keys never have.
+const (
+	ScopeMembersRead  = "members:read"
+	ScopeUsersManage  = "users:manage"
+	ScopeSettingsRead = "settings:read"
+	ScopeTeamsRead    = "teams:read"
+)
+
+var APIKeyScopes = []string{ScopeMembersRead, ScopeUsersManage, ScopeSettingsRead, ScopeTeamsRead}
+
+// APIKeyPrincipal is the caller when a request authenticates with
+// "Authorization: ApiKey <key>".
+type APIKeyPrincipal struct {
+	ID     int
+	OrgID  int
+	Scopes []string
+}
+
+func (p *APIKeyPrincipal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// APIKeyAuthenticator resolves a raw API key to its principal, returning an
+// unauthorized error for unknown, revoked or expired keys.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*APIKeyPrincipal, error)
+}
+
+// CtxAPIKey returns the API key the request authenticated with, or nil for
+// user requests.
+func CtxAPIKey(c *fiber.Ctx) *APIKeyPrincipal {
+	key, _ := c.Locals("apiKey").(*APIKeyPrincipal)
+	return key
+}
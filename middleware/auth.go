@@ -1,47 +1,125 @@
 package middleware
 
 import (
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"org-service/helper"
+	"org-service/jwtauth"
+	"org-service/logging"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
 )
 
-func Authentication(secretKey string) fiber.Handler {
+// Authentication accepts a user JWT ("Bearer <jwt>"), checked by verifier,
+// or an org API key ("ApiKey <key>"), which apiKeys resolves. Users
+// suspended by a platform admin are turned away, as are tokens of revoked
+// sessions. Impersonation tokens also set the acting superadmin (CtxActorID),
+// and every request made with one is audited.
+func Authentication(verifier *jwtauth.Verifier, apiKeys APIKeyAuthenticator, db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// Nested groups can run this twice; audit impersonated requests once
+		if c.Locals("userID") != nil || CtxAPIKey(c) != nil {
+			return c.Next()
+		}
+
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).SendString("Missing or invalid token")
+			return helper.Unauthorized("missing_token", "Missing or invalid token")
+		}
+
+		if scheme, rawKey, ok := strings.Cut(authHeader, " "); ok && strings.EqualFold(scheme, "ApiKey") {
+			key, err := apiKeys.AuthenticateAPIKey(c.UserContext(), strings.TrimSpace(rawKey))
+			if err != nil {
+				return err
+			}
+			c.Locals("apiKey", key)
+			logging.AddCtxAttrs(c, "api_key_id", key.ID)
+			return c.Next()
 		}
 
 		// Remove "Bearer " prefix if present
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).SendString("Invalid token")
+		claims, err := verifier.Verify(c.UserContext(), tokenString)
+		if err != nil {
+			return err
+		}
+		userID, ok := claims["userId"].(float64)
+		if !ok {
+			return helper.Unauthorized("invalid_token", "Invalid token")
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Locals("userID", claims["userId"])
-			return c.Next()
-		} else {
-			return c.Status(fiber.StatusUnauthorized).SendString("Invalid token")
+		var account struct{ SuspendedAt *time.Time }
+		if err := db.WithContext(c.UserContext()).Table("users").Select("suspended_at").Where("id = ?", int(userID)).Scan(&account).Error; err != nil {
+			return err
+		}
+		if account.SuspendedAt != nil {
+			return helper.Forbidden("account_suspended", "This account is suspended")
+		}
+
+		c.Locals("userID", claims["userId"])
+		c.Locals("tokenHash", hashToken(tokenString))
+		if exp, ok := claims["exp"].(float64); ok {
+			c.Locals("tokenExpiresAt", time.Unix(int64(exp), 0))
+		}
+		logging.AddCtxAttrs(c, "user_id", claims["userId"])
+
+		if actorID, ok := claims["actorId"].(float64); ok {
+			impersonationID, _ := claims["jti"].(string)
+			return impersonate(c, db, int(userID), int(actorID), impersonationID)
 		}
+
+		if iat, ok := claims["iat"].(float64); ok {
+			c.Locals("tokenIssuedAt", time.Unix(int64(iat), 0))
+		}
+		if err := trackSession(c, db, int(userID), sessionKey(int(userID), claims, tokenString)); err != nil {
+			return err
+		}
+		return c.Next()
 	}
 }
 
 func CtxUserID(c *fiber.Ctx) (int, error) {
 	userID, ok := c.Locals("userID").(float64) // JWT claims are often float64
 	if !ok {
-		return 0, errors.New("user ID not found in context")
+		return 0, helper.Unauthorized("unauthorized", "user ID not found in context")
 	}
 	return int(userID), nil
 }
 
+// CtxTokenHash identifies the access token of the request without storing
+// it, e.g. to remember that it passed MFA. Empty for API keys.
+func CtxTokenHash(c *fiber.Ctx) string {
+	hash, _ := c.Locals("tokenHash").(string)
+	return hash
+}
+
+// CtxTokenExpiresAt is when the access token of the request expires.
+func CtxTokenExpiresAt(c *fiber.Ctx) time.Time {
+	expiresAt, _ := c.Locals("tokenExpiresAt").(time.Time)
+	return expiresAt
+}
+
+// CtxSignedInAt is when the session of the request's access token began:
+// the token's iat or, if earlier or without one, when the service first saw
+// the session. Zero for API keys and impersonation tokens.
+func CtxSignedInAt(c *fiber.Ctx) time.Time {
+	startedAt, _ := c.Locals("sessionStartedAt").(time.Time)
+	issuedAt, _ := c.Locals("tokenIssuedAt").(time.Time)
+	if startedAt.IsZero() || (!issuedAt.IsZero() && issuedAt.Before(startedAt)) {
+		return issuedAt
+	}
+	return startedAt
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func CtxOrgID(c *fiber.Ctx) int {
 	usOrgRoleI := c.Locals("userOrgRole")
 	if usOrgRoleI == nil {
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"org-service/helper"
+	"org-service/logging"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body returned for every error response.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []helper.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorHandler is the fiber.Config.ErrorHandler. It maps errors to HTTP
+// statuses with helper.HTTPStatus and writes a problem+json body. Unknown
+// errors become a 500 without leaking their message.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	problem := Problem{
+		Type:     "about:blank",
+		Instance: c.OriginalURL(),
+		Status:   helper.HTTPStatus(err),
+	}
+
+	var appErr *helper.Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+		problem.Code = appErr.Code
+		problem.Detail = appErr.Error()
+		problem.Errors = appErr.Fields
+		if problem.Code == "" {
+			problem.Code = string(appErr.Kind)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		problem.Code = string(helper.KindNotFound)
+		problem.Detail = "record not found"
+	case errors.As(err, &fiberErr):
+		problem.Code = strings.ToLower(strings.ReplaceAll(http.StatusText(fiberErr.Code), " ", "_"))
+		problem.Detail = fiberErr.Message
+	}
+
+	if problem.Code == "" {
+		logging.FromCtx(c).Error("unhandled error", "method", c.Method(), "route", c.Route().Path, "error", err)
+		problem.Status = fiber.StatusInternalServerError
+		problem.Code = "internal_error"
+		problem.Detail = "internal server error"
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	return c.Status(problem.Status).JSON(problem, problemContentType)
+}
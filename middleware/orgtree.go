@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Owners and admins of a parent org act as admins in the orgs below it.
+const inheritedRoleID = 2
+
+// AdministeringOrg returns orgID, or the nearest org above it, in which
+// userID is an active owner or admin, or 0 if there is none. Deleted orgs
+// break the chain.
+func AdministeringOrg(ctx context.Context, db *gorm.DB, orgID, userID int) (int, error) {
+	var ids []int
+	err := db.WithContext(ctx).Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS distance FROM orgs WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT orgs.id, orgs.parent_id, chain.distance + 1 FROM orgs JOIN chain ON orgs.id = chain.parent_id
+			WHERE orgs.deleted_at IS NULL
+		)
+		SELECT chain.id FROM chain JOIN user_org_roles ON user_org_roles.org_id = chain.id
+		WHERE user_org_roles.user_id = ? AND user_org_roles.status = 'active' AND user_org_roles.role_id IN (1, 2)
+		ORDER BY chain.distance
+		LIMIT 1`, orgID, userID).
+		Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// CtxInheritedFrom returns the parent org the user's role in the request's
+// org is inherited from, or 0 if the user is a direct member.
+func CtxInheritedFrom(c *fiber.Ctx) int {
+	orgID, _ := c.Locals("inheritedFrom").(int)
+	return orgID
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"org-service/config"
+	"org-service/helper"
+	"org-service/logging"
+	"org-service/metrics"
+	"org-service/ratelimit"
+)
+
+// RateLimiter throttles routes by name, using the limits configured for that
+// name in config.RateLimitConfig. Routes without configured limits, or every
+// route when rate limiting is disabled, are passed through.
+type RateLimiter interface {
+	// Limit counts the request against the route's per-IP, per-user and
+	// per-org buckets and rejects it with 429 once any bucket is full. Use it
+	// after Authentication/OrgAccess so the user and org are known.
+	Limit(route string) fiber.Handler
+	// Lockout rejects clients identified by subject once failed has matched
+	// the handler's error LockoutConfig.MaxFailures times within the window.
+	// A successful request clears the failure count.
+	Lockout(route string, subject func(c *fiber.Ctx) string, failed func(err error) bool) fiber.Handler
+}
+
+type rateLimiter struct {
+	store ratelimit.Store
+	cfg   config.RateLimitConfig
+}
+
+func NewRateLimiter(store ratelimit.Store, cfg config.RateLimitConfig) RateLimiter {
+	return &rateLimiter{store: store, cfg: cfg}
+}
+
+// ByIP identifies the client by IP address, for use as a Lockout subject.
+// Behind a load balancer this is only the client's own address when
+// PROXY_HEADER and TRUSTED_PROXIES are set.
+func ByIP(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// ByUser identifies the client by authenticated user, falling back to the IP
+// address, for use as a Lockout subject after Authentication.
+func ByUser(c *fiber.Ctx) string {
+	if userID, err := CtxUserID(c); err == nil {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return c.IP()
+}
+
+func (r *rateLimiter) Limit(route string) fiber.Handler {
+	limits, ok := r.cfg.Routes[route]
+	if !r.cfg.Enabled || !ok {
+		return passThrough
+	}
+
+	return func(c *fiber.Ctx) error {
+		type bucket struct {
+			name string
+			key  string
+			rate config.Rate
+		}
+		buckets := []bucket{{"ip", c.IP(), limits.PerIP}}
+		if userID, err := CtxUserID(c); err == nil {
+			buckets = append(buckets, bucket{"user", strconv.Itoa(userID), limits.PerUser})
+		}
+		if orgID := CtxOrgID(c); orgID != 0 {
+			buckets = append(buckets, bucket{"org", strconv.Itoa(orgID), limits.PerOrg})
+		}
+
+		for _, b := range buckets {
+			if b.rate.Limit == 0 {
+				continue
+			}
+			count, resetIn, err := r.store.Incr(c.UserContext(), "rl:"+route+":"+b.name+":"+b.key, b.rate.Window)
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down.
+				logging.FromCtx(c).Error("rate limit store failed", "route", route, "error", err)
+				continue
+			}
+			if count > int64(b.rate.Limit) {
+				metrics.RateLimited(route, b.name)
+				logging.FromCtx(c).Warn("rate limit exceeded", "route", route, "bucket", b.name)
+				setRetryAfter(c, resetIn)
+				return helper.RateLimited("rate_limited", "Too many requests, please retry later")
+			}
+		}
+		return c.Next()
+	}
+}
+
+func (r *rateLimiter) Lockout(route string, subject func(c *fiber.Ctx) string, failed func(err error) bool) fiber.Handler {
+	if !r.cfg.Enabled {
+		return passThrough
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		id := subject(c)
+		lockKey := "lockout:" + route + ":" + id
+		failKey := "failures:" + route + ":" + id
+
+		locked, resetIn, err := r.store.Get(ctx, lockKey)
+		if err != nil {
+			logging.FromCtx(c).Error("rate limit store failed", "route", route, "error", err)
+		}
+		if locked > 0 {
+			metrics.RateLimited(route, "lockout")
+			setRetryAfter(c, resetIn)
+			return helper.RateLimited("too_many_attempts", "Too many failed attempts, please retry later")
+		}
+
+		err = c.Next()
+		switch {
+		case err != nil && failed(err):
+			r.recordFailure(ctx, c, route, lockKey, failKey)
+		case err == nil:
+			if derr := r.store.Delete(ctx, failKey); derr != nil {
+				logging.FromCtx(c).Error("rate limit store failed", "route", route, "error", derr)
+			}
+		}
+		return err
+	}
+}
+
+func (r *rateLimiter) recordFailure(ctx context.Context, c *fiber.Ctx, route, lockKey, failKey string) {
+	lockout := r.cfg.Lockout
+	failures, _, err := r.store.Incr(ctx, failKey, lockout.Window)
+	if err != nil {
+		logging.FromCtx(c).Error("rate limit store failed", "route", route, "error", err)
+		return
+	}
+	if failures < int64(lockout.MaxFailures) {
+		return
+	}
+
+	if _, _, err := r.store.Incr(ctx, lockKey, lockout.Duration); err != nil {
+		logging.FromCtx(c).Error("rate limit store failed", "route", route, "error", err)
+		return
+	}
+	_ = r.store.Delete(ctx, failKey)
+	logging.FromCtx(c).Warn("client locked out after repeated failures",
+		"route", route, "failures", failures, "duration", lockout.Duration.String())
+}
+
+func setRetryAfter(c *fiber.Ctx, d time.Duration) {
+	seconds := max(1, int(math.Ceil(d.Seconds())))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+}
+
+func passThrough(c *fiber.Ctx) error {
+	return c.Next()
+}
@@ -1,18 +1,33 @@
 package middleware
 
 import (
+	"errors"
+	"org-service/helper"
+	"org-service/logging"
+	"org-service/metrics"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-type HTTPError struct {
-	Message string `json:"message"`
-}
+// PlatformRoleSuperadmin is the platform role (users.platform_role) of the
+// service operator's support staff, who administer users and orgs across
+// orgs.
+const PlatformRoleSuperadmin = "superadmin"
+
+// user_org_roles.last_accessed_at is written at most this often per member.
+const lastAccessResolution = time.Minute
 
 // Role-Based Access Control
 type RBAC interface {
 	OrgAccess(c *fiber.Ctx) error
 	RolePermissions(c *fiber.Ctx) error
+	RequireScope(scope string) fiber.Handler
+	RequireTeamRole(role string) fiber.Handler
+	PlatformAdmin(c *fiber.Ctx) error
+	RequireMFA(c *fiber.Ctx) error
 }
 
 type rbac struct {
@@ -26,44 +41,162 @@ func NewRBAC(db *gorm.DB) RBAC {
 }
 
 func (r rbac) OrgAccess(c *fiber.Ctx) error {
-	ctxUserId, err := CtxUserID(c)
-	if err != nil {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(HTTPError{Message: "Invalid UserID"})
-	}
-
 	// Pull and handle orgId from URL param
 	orgIdParam := c.Params("orgId")
 	if orgIdParam == "" {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(HTTPError{Message: "Invalid OrgID param"})
+		return helper.FieldRequired("orgId")
+	}
+
+	// Suspended orgs are closed to members and API keys alike
+	var org struct{ SuspendedAt *time.Time }
+	if err := r.db.WithContext(c.UserContext()).Table("orgs").Select("suspended_at").Where("id = ?", orgIdParam).Scan(&org).Error; err != nil {
+		return err
+	}
+	if org.SuspendedAt != nil {
+		metrics.RBACDenied("org_suspended")
+		return helper.Forbidden("org_suspended", "This org is suspended")
+	}
+
+	// API keys belong to exactly one org and have no role in it
+	if key := CtxAPIKey(c); key != nil {
+		if strconv.Itoa(key.OrgID) != orgIdParam {
+			metrics.RBACDenied("org_access")
+			return helper.Forbidden("org_access_denied", "Org access denied")
+		}
+		c.Locals("userOrgRole", UserOrgRole{OrgID: key.OrgID})
+		logging.AddCtxAttrs(c, "org_id", key.OrgID)
+		return c.Next()
+	}
+
+	ctxUserId, err := CtxUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// Check and handle in DB if relationship exists
 	var userOrgRole UserOrgRole
-	result := r.db.Where("user_id = ? AND org_id = ?", ctxUserId, orgIdParam).First(&userOrgRole)
+	result := r.db.WithContext(c.UserContext()).Where("user_id = ? AND org_id = ?", ctxUserId, orgIdParam).First(&userOrgRole)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return result.Error
+	}
+	// Invited, pending, deactivated and rejected members have no access,
+	// not even one inherited from a parent org
+	if result.Error == nil && userOrgRole.Status != "active" {
+		metrics.RBACDenied("membership_inactive")
+		return helper.Forbidden("membership_inactive", "Your membership in this org is not active")
+	}
+	// Without a direct membership, owners and admins of a parent org get
+	// the inherited role
+	if result.Error != nil {
+		orgId, err := strconv.Atoi(orgIdParam)
+		if err != nil {
+			return helper.FieldInvalid("orgId", "must be a number")
+		}
+		inheritedFrom, err := AdministeringOrg(c.UserContext(), r.db, orgId, ctxUserId)
+		if err != nil {
+			return err
+		}
+		if inheritedFrom == 0 {
+			metrics.RBACDenied("org_access")
+			return helper.Forbidden("org_access_denied", "Org access denied")
+		}
+		userOrgRole = UserOrgRole{UserID: ctxUserId, OrgID: orgId, RoleID: inheritedRoleID, Status: "active"}
+		c.Locals("inheritedFrom", inheritedFrom)
+		logging.AddCtxAttrs(c, "inherited_from", inheritedFrom)
+	}
+
+	// Orgs can require members to have verified their email
+	var verification struct {
+		RequireVerifiedEmail bool
+		VerifiedEmail        bool
+		LastAccessedAt       *time.Time
+		SessionsRevokedAt    *time.Time
+	}
+	result = r.db.WithContext(c.UserContext()).Table("users").
+		Select("COALESCE(org_settings.require_verified_email, FALSE) AS require_verified_email, users.verified_email, user_org_roles.last_accessed_at, user_org_roles.sessions_revoked_at").
+		Joins("LEFT JOIN org_settings ON org_settings.org_id = ?", userOrgRole.OrgID).
+		Joins("LEFT JOIN user_org_roles ON user_org_roles.user_id = users.id AND user_org_roles.org_id = ?", userOrgRole.OrgID).
+		Where("users.id = ?", ctxUserId).
+		Scan(&verification)
 	if result.Error != nil {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(HTTPError{Message: "Org access denied"})
+		return result.Error
+	}
+	if verification.RequireVerifiedEmail && !verification.VerifiedEmail {
+		metrics.RBACDenied("email_not_verified")
+		return helper.Forbidden("email_not_verified", "Verify your email address to access this org")
+	}
+
+	// A member signed out of this org by an admin must sign in again
+	if revokedAt := verification.SessionsRevokedAt; revokedAt != nil {
+		if signedInAt := CtxSignedInAt(c); !signedInAt.IsZero() && !signedInAt.After(*revokedAt) {
+			metrics.RBACDenied("session_revoked")
+			return helper.Unauthorized("session_revoked", "This session has been signed out of this org")
+		}
+	}
+
+	// Record the visit for the org switcher; inherited access has no
+	// membership to record it on
+	now := time.Now()
+	if CtxInheritedFrom(c) == 0 && (verification.LastAccessedAt == nil || now.Sub(*verification.LastAccessedAt) >= lastAccessResolution) {
+		err := r.db.WithContext(c.UserContext()).Table("user_org_roles").
+			Where("user_id = ? AND org_id = ?", ctxUserId, userOrgRole.OrgID).
+			Update("last_accessed_at", now).Error
+		if err != nil {
+			logging.FromCtx(c).Warn("failed to record org access", "org_id", userOrgRole.OrgID, "error", err)
+		}
 	}
 
 	// save the userOrgRole record ctx locals
 	c.Locals("userOrgRole", userOrgRole)
-	c.Next()
-	return nil
+	logging.AddCtxAttrs(c, "org_id", userOrgRole.OrgID, "role_id", userOrgRole.RoleID)
+	return c.Next()
+}
+
+// PlatformAdmin lets only platform superadmins through. Their role is outside
+// any org, so API keys never have it.
+func (r rbac) PlatformAdmin(c *fiber.Ctx) error {
+	if CtxAPIKey(c) != nil {
+		metrics.RBACDenied("platform_admin")
+		return helper.Forbidden("permission_denied", "API keys cannot use platform admin routes")
+	}
+	userID, err := CtxUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var user struct{ PlatformRole string }
+	if err := r.db.WithContext(c.UserContext()).Table("users").Select("platform_role").Where("id = ?", userID).Scan(&user).Error; err != nil {
+		return err
+	}
+	if user.PlatformRole != PlatformRoleSuperadmin {
+		metrics.RBACDenied("platform_admin")
+		return helper.Forbidden("permission_denied", "Platform admins only")
+	}
+	logging.AddCtxAttrs(c, "platform_role", user.PlatformRole)
+	return c.Next()
+}
+
+// RequireScope lets API keys through only if they were granted scope. User
+// requests pass; their access is governed by roles.
+func (r rbac) RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := CtxAPIKey(c); key != nil && !key.HasScope(scope) {
+			metrics.RBACDenied("api_key_scope")
+			return helper.Forbidden("insufficient_scope", "API key is missing the "+scope+" scope")
+		}
+		return c.Next()
+	}
 }
 
 func (r rbac) RolePermissions(c *fiber.Ctx) error {
 	// Handle userOrgRole saved in ctx
 	usOrgRoleI := c.Locals("userOrgRole")
 	if usOrgRoleI == nil {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(HTTPError{Message: "Invalid User Role"})
+		return helper.Forbidden("invalid_user_role", "Invalid User Role")
 	}
 	usOrgRole, ok := usOrgRoleI.(UserOrgRole)
 	if !ok {
-		c.Status(fiber.StatusInternalServerError)
-		return c.JSON(HTTPError{Message: "Error converting UserOrgRole interface to struct"})
+		return errors.New("error converting UserOrgRole interface to struct")
 	}
 
 	// Collect request route's path and HTTP method
@@ -72,20 +205,18 @@ func (r rbac) RolePermissions(c *fiber.Ctx) error {
 
 	// Check in DB if the permission is allowed for usOrgRole.role_id
 	var count int64
-	result := r.db.Table("role_permissions").
+	result := r.db.WithContext(c.UserContext()).Table("role_permissions").
 		Where("roles.id = ? AND permissions.http_method = ? AND permissions.path = ?", usOrgRole.RoleID, reqRouteMethod, reqRoutePath).
 		Joins("JOIN roles ON roles.id = role_permissions.role_id").
 		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
 		Count(&count)
 	if result.Error != nil {
-		c.Status(fiber.StatusInternalServerError)
-		return c.JSON(HTTPError{Message: result.Error.Error()})
+		return result.Error
 	}
 	if count == 0 {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(HTTPError{Message: "Permission denied"})
+		metrics.RBACDenied("permission")
+		return helper.Forbidden("permission_denied", "Permission denied")
 	}
 
-	c.Next()
-	return nil
+	return c.Next()
 }
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"org-service/logging"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// Incoming IDs are echoed back and logged, so only accept harmless ones.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+// RequestLogger assigns each request an X-Request-ID (reusing a valid
+// incoming one), attaches a request-scoped logger to the context and writes
+// one access log line when the request completes. The route pattern is
+// logged rather than the raw path, which can carry emails and tokens. It is
+// the outermost middleware and the one place errors are rendered, through
+// the app's ErrorHandler.
+func RequestLogger(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(HeaderRequestID)
+		if !requestIDRegex.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(HeaderRequestID, requestID)
+		logging.SetCtx(c, logger.With("request_id", requestID))
+
+		if err := c.Next(); err != nil {
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+		status := c.Response().StatusCode()
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		// user_id and org_id are already on the logger when auth/RBAC ran.
+		logging.FromCtx(c).Log(c.UserContext(), level, "request completed",
+			"method", c.Method(),
+			"route", route,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+		)
+		return nil
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
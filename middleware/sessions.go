@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"org-service/helper"
+	"org-service/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// user_sessions.last_active_at, ip and user_agent are written at most this
+// often per session.
+const sessionActivityResolution = time.Minute
+
+const maxUserAgentLength = 512
+
+// Expired sessions are deleted after this long, well past the JWT leeway,
+// so the tokens of a revoked session cannot start it again.
+const expiredSessionRetention = 24 * time.Hour
+
+type session struct {
+	ID           int `gorm:"primaryKey"`
+	UserID       int
+	SessionKey   string
+	UserAgent    string
+	IP           string `gorm:"column:ip"`
+	CreatedAt    time.Time
+	LastActiveAt time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+}
+
+func (session) TableName() string {
+	return "user_sessions"
+}
+
+// CtxSessionID is the user_sessions row of the request's access token, or 0
+// for API keys and impersonation tokens.
+func CtxSessionID(c *fiber.Ctx) int {
+	sessionID, _ := c.Locals("sessionID").(int)
+	return sessionID
+}
+
+// sessionKey names the session that token belongs to. The tokens of one sign-in
+// share its sid claim. A token without one is a session of its own, named by
+// its jti or, failing that, by the token itself.
+func sessionKey(userID int, claims jwt.MapClaims, token string) string {
+	if sid, _ := claims["sid"].(string); sid != "" {
+		return hashToken(fmt.Sprintf("sid:%d:%s", userID, sid))
+	}
+	if jti, _ := claims["jti"].(string); jti != "" {
+		return hashToken(fmt.Sprintf("jti:%d:%s", userID, jti))
+	}
+	return hashToken(token)
+}
+
+// trackSession finds or starts the session with the given key. Revoked
+// sessions are rejected; for the others the device, IP and last activity are
+// kept up to date.
+func trackSession(c *fiber.Ctx, db *gorm.DB, userID int, key string) error {
+	db = db.WithContext(c.UserContext())
+
+	now := time.Now()
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	expiresAt := CtxTokenExpiresAt(c)
+
+	var s session
+	err := db.Where("session_key = ?", key).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s = session{UserID: userID, SessionKey: key, UserAgent: userAgent, IP: c.IP(), CreatedAt: now, LastActiveAt: now, ExpiresAt: expiresAt}
+		// A concurrent request may have started it first
+		err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&s).Error
+		if err == nil && s.ID == 0 {
+			err = db.Where("session_key = ?", key).First(&s).Error
+		}
+		// Tokens without a sid start a session each, so drop the user's
+		// expired ones while at it
+		if err == nil {
+			if err := db.Where("user_id = ? AND expires_at < ?", userID, now.Add(-expiredSessionRetention)).Delete(&session{}).Error; err != nil {
+				logging.FromCtx(c).Warn("failed to delete expired sessions", "user_id", userID, "error", err)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.UserID != userID {
+		return helper.Unauthorized("invalid_token", "Invalid token")
+	}
+	if s.RevokedAt != nil {
+		return helper.Unauthorized("session_revoked", "This session has been signed out")
+	}
+
+	if now.Sub(s.LastActiveAt) >= sessionActivityResolution || expiresAt.After(s.ExpiresAt) {
+		updates := map[string]interface{}{"last_active_at": now, "ip": c.IP(), "user_agent": userAgent}
+		// Refreshed tokens of the same session extend it
+		if expiresAt.After(s.ExpiresAt) {
+			updates["expires_at"] = expiresAt
+		}
+		if err := db.Model(&session{}).Where("id = ?", s.ID).Updates(updates).Error; err != nil {
+			logging.FromCtx(c).Warn("failed to record session activity", "session_id", s.ID, "error", err)
+		}
+	}
+
+	c.Locals("sessionID", s.ID)
+	c.Locals("sessionStartedAt", s.CreatedAt)
+	logging.AddCtxAttrs(c, "session_id", s.ID)
+	return nil
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSessionKey(t *testing.T) {
+	type token struct {
+		userID int
+		claims jwt.MapClaims
+		raw    string
+	}
+	tests := []struct {
+		name string
+		a, b token
+		same bool
+	}{
+		{"same sid, refreshed token", token{1, jwt.MapClaims{"sid": "s1", "jti": "j1"}, "t1"}, token{1, jwt.MapClaims{"sid": "s1", "jti": "j2"}, "t2"}, true},
+		{"different sid", token{1, jwt.MapClaims{"sid": "s1"}, "t1"}, token{1, jwt.MapClaims{"sid": "s2"}, "t1"}, false},
+		{"same sid, other user", token{1, jwt.MapClaims{"sid": "s1"}, "t1"}, token{2, jwt.MapClaims{"sid": "s1"}, "t1"}, false},
+		{"same jti", token{1, jwt.MapClaims{"jti": "j1"}, "t1"}, token{1, jwt.MapClaims{"jti": "j1"}, "t2"}, true},
+		{"different jti", token{1, jwt.MapClaims{"jti": "j1"}, "t1"}, token{1, jwt.MapClaims{"jti": "j2"}, "t1"}, false},
+		{"same jti, other user", token{1, jwt.MapClaims{"jti": "j1"}, "t1"}, token{2, jwt.MapClaims{"jti": "j1"}, "t1"}, false},
+		{"sid and jti do not collide", token{1, jwt.MapClaims{"sid": "x"}, "t1"}, token{1, jwt.MapClaims{"jti": "x"}, "t1"}, false},
+		{"no sid or jti, same token", token{1, jwt.MapClaims{}, "t1"}, token{1, jwt.MapClaims{"sid": ""}, "t1"}, true},
+		{"no sid or jti, other token", token{1, jwt.MapClaims{}, "t1"}, token{1, jwt.MapClaims{}, "t2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := sessionKey(tt.a.userID, tt.a.claims, tt.a.raw)
+			b := sessionKey(tt.b.userID, tt.b.claims, tt.b.raw)
+			if (a == b) != tt.same {
+				t.Errorf("same key = %v, want %v", a == b, tt.same)
+			}
+		})
+	}
+}
+
+func TestCtxSignedInAt(t *testing.T) {
+	early := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	tests := []struct {
+		name      string
+		issuedAt  time.Time
+		startedAt time.Time
+		want      time.Time
+	}{
+		{"issued before the session was seen", early, late, early},
+		{"session started before the token was issued", late, early, early},
+		{"no iat", time.Time{}, late, late},
+		{"no session", early, time.Time{}, early},
+		{"neither", time.Time{}, time.Time{}, time.Time{}},
+	}
+
+	app := fiber.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := app.AcquireCtx(&fasthttp.RequestCtx{})
+			defer app.ReleaseCtx(c)
+			if !tt.issuedAt.IsZero() {
+				c.Locals("tokenIssuedAt", tt.issuedAt)
+			}
+			if !tt.startedAt.IsZero() {
+				c.Locals("sessionStartedAt", tt.startedAt)
+			}
+			if got := CtxSignedInAt(c); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"org-service/helper"
+	"org-service/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Team-level roles. A lead manages the team, its members and its sub-teams;
+// a member only belongs to it.
+const (
+	TeamRoleLead   = "lead"
+	TeamRoleMember = "member"
+)
+
+var TeamRoles = []string{TeamRoleLead, TeamRoleMember}
+
+// Roles that satisfy a required team role; a lead is also a member.
+var satisfyingTeamRoles = map[string][]string{
+	TeamRoleLead:   {TeamRoleLead},
+	TeamRoleMember: {TeamRoleLead, TeamRoleMember},
+}
+
+// HasTeamRole reports whether userID holds role, or a higher one, in team
+// teamID of orgID or in any team above it: the lead of a team leads all of
+// its sub-teams.
+func HasTeamRole(ctx context.Context, db *gorm.DB, orgID, userID, teamID int, role string) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM teams WHERE id = ? AND org_id = ?
+			UNION ALL
+			SELECT teams.id, teams.parent_id FROM teams JOIN chain ON teams.id = chain.parent_id
+		)
+		SELECT COUNT(*) FROM team_members JOIN chain ON team_members.team_id = chain.id
+		WHERE team_members.user_id = ? AND team_members.role IN ?`,
+		teamID, orgID, userID, satisfyingTeamRoles[role]).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// RequireTeamRole lets a request through if the user holds role in the team
+// named by the :teamId param, directly or through a parent team. Org owners
+// and admins hold every team role. API keys have no team roles.
+func (r rbac) RequireTeamRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := CtxUserID(c)
+		if err != nil {
+			return err
+		}
+		if roleID := CtxRoleID(c); roleID == 1 || roleID == 2 {
+			return c.Next()
+		}
+
+		teamID, err := strconv.Atoi(c.Params("teamId"))
+		if err != nil {
+			return helper.FieldInvalid("teamId", "must be a number")
+		}
+		ok, err := HasTeamRole(c.UserContext(), r.db, CtxOrgID(c), userID, teamID, role)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			metrics.RBACDenied("team_role")
+			return helper.Forbidden("permission_denied", "requires the "+role+" role in this team")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"org-service/db"
+)
+
+const migrateUsage = `usage: org-service migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down [n]     revert the last n migrations (default 1)
+  status       list migrations and when they were applied
+  to <version> migrate up or down to the given version (0 reverts all)`
+
+// runMigrate handles `org-service migrate ...`.
+func runMigrate(conn *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := db.MigrateUp(conn); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		if err := db.MigrateDown(conn, steps); err != nil {
+			return err
+		}
+	case "to":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := db.MigrateTo(conn, version); err != nil {
+			return err
+		}
+	case "status":
+		return printMigrationStatus(conn)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	version, err := db.CurrentMigrationVersion(conn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema is at version %d\n", version)
+	return nil
+}
+
+func printMigrationStatus(conn *gorm.DB) error {
+	statuses, err := db.GetMigrationStatus(conn)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
+
+// warnPendingMigrations logs when the database is behind the embedded
+// migrations; the server does not migrate on startup.
+func warnPendingMigrations(conn *gorm.DB, logger *slog.Logger) error {
+	current, err := db.CurrentMigrationVersion(conn)
+	if err != nil {
+		return err
+	}
+	latest, err := db.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		logger.Warn("database schema is behind; run `org-service migrate up`", "version", current, "latest", latest)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package org
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"org-service/db/dbtest"
+	"org-service/helper"
+)
+
+const parallelRequests = 8
+
+func TestAddOrgConcurrentSameName(t *testing.T) {
+	conn := dbtest.Open(t)
+	svc := NewOrgService(conn, nil, 0, dbtest.Logger())
+	name := dbtest.Unique("Acme ")
+
+	userIDs := make([]int, parallelRequests)
+	for i := range userIDs {
+		userIDs[i] = dbtest.CreateUser(t, conn, dbtest.Unique("owner")+"@example.com")
+	}
+
+	errs := make([]error, parallelRequests)
+	var wg sync.WaitGroup
+	for i := range parallelRequests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.AddOrg(context.Background(), &AddOrgRequest{Name: name, Size: "1-10", UserID: userIDs[i]})
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case helper.HasCode(err, "org_name_taken"), helper.HasCode(err, "org_slug_taken"):
+		default:
+			t.Errorf("AddOrg: unexpected error %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("AddOrg succeeded %d times, want 1", created)
+	}
+
+	var orgs []Org
+	if err := conn.Where("name = ?", name).Find(&orgs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(orgs) != 1 {
+		t.Fatalf("got %d orgs named %q, want 1", len(orgs), name)
+	}
+
+	var memberships int64
+	if err := conn.Table(UserOrgRoleTableName).Where("org_id = ?", orgs[0].ID).Count(&memberships).Error; err != nil {
+		t.Fatal(err)
+	}
+	if memberships != 1 {
+		t.Errorf("got %d memberships in the new org, want 1", memberships)
+	}
+}
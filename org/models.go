@@ -1,10 +1,14 @@
 package org
 
+import "time"
+
 type OrgResponse struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-	Size string `json:"size"`
-	Slug string `json:"slug"`
+	ID                   int    `json:"id"`
+	Name                 string `json:"name"`
+	Size                 string `json:"size"`
+	Slug                 string `json:"slug"`
+	ParentID             *int   `json:"parentId"`
+	RequireVerifiedEmail bool   `json:"requireVerifiedEmail"`
 }
 
 type User struct {
@@ -24,31 +28,64 @@ type User struct {
 }
 
 type AddOrgRequest struct {
-	Name   string `json:"name"`
-	Size   string `json:"size"`
+	Name                 string `json:"name" validate:"required,min=2,max=100"`
+	Size                 string `json:"size" validate:"required,orgsize"`
+	Slug                 string `json:"slug" validate:"omitempty,slug,max=100"`
+	RequireVerifiedEmail bool   `json:"requireVerifiedEmail"`
+	ParentID             *int   `json:"parentId" validate:"omitempty,min=1"`
+	UserID               int    `json:"-"`
+}
+
+// FindMyOrgsRequest filters the orgs by the user's membership status;
+// "all" lists every membership. Empty means active.
+type FindMyOrgsRequest struct {
 	UserID int    `json:"-"`
+	Status string `json:"-" validate:"omitempty,oneof=active inactive invited pending rejected all"`
 }
 
-type IDRequest struct {
-	ID     int `json:"id"`
-	UserID int `json:"-"`
+// SetDefaultOrgRequest pins the org the UI opens first; an orgId of 0 unpins
+// it.
+type SetDefaultOrgRequest struct {
+	OrgID  *int `json:"orgId" validate:"required,min=0"`
+	UserID int  `json:"-"`
+}
+
+type DefaultOrgResponse struct {
+	OrgID *int `json:"orgId"`
 }
 
 type GetOrgsResponse struct {
 	Orgs []OrgResponse `json:"orgs"`
 }
 
+// OrgWithRole is an org in the user's org switcher. MemberCount counts
+// active members. IsDefault marks the org the user pinned or, without a pin,
+// the active membership accessed last.
 type OrgWithRole struct {
-	OrgID  int    `json:"orgId"`
-	RoleID int    `json:"roleId"`
-	Name   string `json:"name"`
-	Slug   string `json:"slug"`
-	UserID int    `json:"userId"`
+	OrgID          int        `json:"orgId"`
+	RoleID         int        `json:"roleId"`
+	RoleName       string     `json:"roleName"`
+	Status         string     `json:"status"`
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	UserID         int        `json:"userId"`
+	MemberCount    int        `json:"memberCount"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+	IsDefault      bool       `json:"isDefault"`
 }
 
 type OrgRequest struct {
-	UserID int `json:"-"`
-	OrgID  int `json:"-"`
+	UserID   int `json:"-"`
+	APIKeyID int `json:"-"`
+	OrgID    int `json:"-" validate:"required"`
+}
+
+// GetOrgMembersRequest can narrow the listing to the members of a team and,
+// with IncludeSubteams, of the teams nested inside it.
+type GetOrgMembersRequest struct {
+	OrgRequest
+	TeamID          int  `json:"-" validate:"omitempty,min=1"`
+	IncludeSubteams bool `json:"-"`
 }
 
 type UserOrgRoleResponse struct {
@@ -59,15 +96,16 @@ type UserOrgRoleResponse struct {
 }
 
 type UserResponse struct {
-	ID           int    `json:"id"`
-	Email        string `json:"email"`
-	Username     string `json:"username"`
-	FirstName    string `json:"firstName"`
-	LastName     string `json:"lastName"`
-	Status       string `json:"status"`
-	AvatarImgKey string `json:"avatarImgKey"`
-	Active       bool   `json:"active"`
-	Phone        string `json:"phone"`
+	ID           int            `json:"id"`
+	Email        string         `json:"email"`
+	Username     string         `json:"username"`
+	FirstName    string         `json:"firstName"`
+	LastName     string         `json:"lastName"`
+	Status       string         `json:"status"`
+	AvatarImgKey string         `json:"avatarImgKey"`
+	AvatarURLs   map[int]string `json:"avatarUrls,omitempty"`
+	Active       bool           `json:"active"`
+	Phone        string         `json:"phone"`
 }
 
 type OrgMembers struct {
@@ -78,3 +116,64 @@ type OrgMembers struct {
 type OrgMembersResponse struct {
 	OrgMembers []OrgMembers `json:"orgMembers"`
 }
+
+type OrgSettingsResponse struct {
+	OrgID                int        `json:"orgId"`
+	LogoURL              string     `json:"logoUrl"`
+	PrimaryColor         string     `json:"primaryColor"`
+	DefaultLocale        string     `json:"defaultLocale"`
+	Timezone             string     `json:"timezone"`
+	DefaultRoleID        *int       `json:"defaultRoleId"`
+	JoinPolicy           string     `json:"joinPolicy"`
+	InvitationTTLHours   *int       `json:"invitationTtlHours"`
+	RequireVerifiedEmail bool       `json:"requireVerifiedEmail"`
+	RequireMFAForAdmins  bool       `json:"requireMfaForAdmins"`
+	UpdatedAt            *time.Time `json:"updatedAt"`
+}
+
+// UpdateOrgSettingsRequest changes only the fields that are present. An empty
+// logoUrl or primaryColor, and a defaultRoleId or invitationTtlHours of 0,
+// clear the setting. Only users with MFA enabled can turn on
+// requireMfaForAdmins, so they do not lock themselves out.
+type UpdateOrgSettingsRequest struct {
+	OrgID                int     `json:"-" validate:"required"`
+	CurrentUserID        int     `json:"-"`
+	CurrentRoleID        int     `json:"-"`
+	LogoURL              *string `json:"logoUrl" validate:"omitempty,max=2048,eq=|http_url"`
+	PrimaryColor         *string `json:"primaryColor" validate:"omitempty,eq=|hexcolor"`
+	DefaultLocale        *string `json:"defaultLocale" validate:"omitempty,bcp47_language_tag"`
+	Timezone             *string `json:"timezone" validate:"omitempty,timezone"`
+	DefaultRoleID        *int    `json:"defaultRoleId" validate:"omitempty,eq=0|roleid"`
+	JoinPolicy           *string `json:"joinPolicy" validate:"omitempty,oneof=invite_only approval_required"`
+	InvitationTTLHours   *int    `json:"invitationTtlHours" validate:"omitempty,eq=0|min=1,max=720"`
+	RequireVerifiedEmail *bool   `json:"requireVerifiedEmail"`
+	RequireMFAForAdmins  *bool   `json:"requireMfaForAdmins"`
+}
+
+// SetParentRequest moves the org under another org; a parentId of 0 detaches
+// it.
+type SetParentRequest struct {
+	ParentID      *int `json:"parentId" validate:"required,min=0"`
+	OrgID         int  `json:"-" validate:"required"`
+	CurrentUserID int  `json:"-"`
+	CurrentRoleID int  `json:"-"`
+}
+
+type OrgChildrenRequest struct {
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+type OrgTreeNode struct {
+	ID       int           `json:"id"`
+	Name     string        `json:"name"`
+	Slug     string        `json:"slug"`
+	ParentID *int          `json:"parentId"`
+	Children []OrgTreeNode `json:"children"`
+}
+
+// OrgChildrenResponse is the tree of orgs below the org, nearest first.
+type OrgChildrenResponse struct {
+	Children []OrgTreeNode `json:"children"`
+}
@@ -1,11 +1,20 @@
 package org
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"org-service/middleware"
 
-func RegisterRoutes(router fiber.Router, orgRoute fiber.Router, orgHttpApi OrgHTTPTransport, authMiddleware func(c *fiber.Ctx) error) {
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterRoutes(router fiber.Router, orgRoute fiber.Router, orgHttpApi OrgHTTPTransport, authMiddleware func(c *fiber.Ctx) error, rbac middleware.RBAC) {
 	orgRoutes := router.Group("/orgs")
 	orgRoutes.Post("/", authMiddleware, orgHttpApi.AddOrg)
 	orgRoutes.Get("/me", authMiddleware, orgHttpApi.FindMyOrgs)
+	orgRoutes.Put("/me/default", authMiddleware, orgHttpApi.SetDefaultOrg)
 
-	orgRoute.Get("/members", authMiddleware, orgHttpApi.GetOrgMembers)
+	orgRoute.Get("/members", authMiddleware, rbac.RequireScope(middleware.ScopeMembersRead), orgHttpApi.GetOrgMembers)
+	orgRoute.Get("/settings", rbac.RequireScope(middleware.ScopeSettingsRead), orgHttpApi.GetSettings)
+	orgRoute.Patch("/settings", rbac.RequireMFA, orgHttpApi.UpdateSettings)
+	orgRoute.Put("/parent", rbac.RequireMFA, orgHttpApi.SetParent)
+	orgRoute.Get("/children", orgHttpApi.GetChildren)
 }
\ No newline at end of file
@@ -9,6 +9,18 @@ import (
 const (
 	OrgTableName = "orgs"
 	UserOrgRoleTableName = "user_org_roles"
+	OrgSettingsTableName = "org_settings"
+	UserTableName = "users"
+)
+
+// Join policies an org can have.
+const (
+	// JoinPolicyInviteOnly: members join by invitation. Invitations sent by
+	// owners and admins need no approval, those sent by others do.
+	JoinPolicyInviteOnly = "invite_only"
+	// JoinPolicyApprovalRequired: every accepted invitation waits for an
+	// owner or admin to approve the member.
+	JoinPolicyApprovalRequired = "approval_required"
 )
 
 type Org struct {
@@ -16,6 +28,7 @@ type Org struct {
 	Name           string        `gorm:"not null"`
 	Size           string        `gorm:"not null"`
 	Slug           string        `gorm:"unique;not null"`
+	ParentID       *int
 	UserOrgRole    []UserOrgRole `gorm:"foreignKey:OrgID"`
 	// SubscriptionID int           `gorm:"foreignKey:ID"`
 	// Subscription   Subscription
@@ -25,9 +38,9 @@ type Org struct {
 }
 
 type UserOrgRole struct {
-	UserID int `gorm:"foreignKey:ID"`
+	UserID int `gorm:"primaryKey;autoIncrement:false"`
 	User   User
-	OrgID  int `gorm:"foreignKey:ID"`
+	OrgID  int `gorm:"primaryKey;autoIncrement:false"`
 	Org    Org
 	RoleID int `gorm:"foreignKey:ID"`
 	Role   Role
@@ -38,3 +51,62 @@ type UserOrgRole struct {
 type Role struct {
 	gorm.Model
 }
+
+// OrgSettings holds the branding and membership settings of an org. Orgs
+// without a stored row use DefaultSettings.
+type OrgSettings struct {
+	OrgID                int `gorm:"primaryKey;autoIncrement:false"`
+	LogoURL              string
+	PrimaryColor         string
+	DefaultLocale        string
+	Timezone             string
+	DefaultRoleID        *int
+	JoinPolicy           string
+	InvitationTTLHours   *int
+	RequireVerifiedEmail bool
+	RequireMFAForAdmins  bool `gorm:"column:require_mfa_for_admins"`
+	UpdatedBy            *int
+	UpdatedAt            *time.Time
+}
+
+func (OrgSettings) TableName() string {
+	return OrgSettingsTableName
+}
+
+// DefaultSettings are the settings of an org nobody has configured.
+func DefaultSettings(orgID int) OrgSettings {
+	return OrgSettings{
+		OrgID:         orgID,
+		DefaultLocale: "en",
+		Timezone:      "UTC",
+		JoinPolicy:    JoinPolicyInviteOnly,
+	}
+}
+
+// LoadSettings returns the settings of the org, or DefaultSettings if none
+// are stored.
+func LoadSettings(db *gorm.DB, orgID int) (OrgSettings, error) {
+	settings := DefaultSettings(orgID)
+	if err := db.Where("org_id = ?", orgID).Limit(1).Find(&settings).Error; err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// InvitationTTL is how long invitations of the org stay valid, or fallback
+// if the org has not set it.
+func (s OrgSettings) InvitationTTL(fallback time.Duration) time.Duration {
+	if s.InvitationTTLHours == nil {
+		return fallback
+	}
+	return time.Duration(*s.InvitationTTLHours) * time.Hour
+}
+
+// Location is the org's timezone, or UTC if it cannot be loaded.
+func (s OrgSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -1,29 +1,62 @@
 package org
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"org-service/audit"
+	"org-service/avatars"
 	"org-service/helper"
+	"org-service/middleware"
+	"org-service/storage"
+	"org-service/teams"
 
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/gofiber/fiber/v2/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Audit actions recorded by the org service.
+const (
+	AuditActionSettingsUpdated = "org.settings.updated"
+	AuditActionParentChanged   = "org.parent.changed"
+)
+
+// Members with this status count as members of the org.
+const memberStatusActive = "active"
+
+// Key of the advisory lock that serializes changes to org parents, so two
+// concurrent moves cannot form a cycle.
+const orgTreeLockKey = 7_243_001
+
 type orgApi struct {
 	db *gorm.DB
-	logger log.AllLogger
+	blobs storage.BlobStore
+	urlTTL time.Duration
+	logger *slog.Logger
+	validate *helper.Validator
 }
 
 type OrgAPI interface {
-	AddOrg(req *AddOrgRequest) (res *OrgResponse, err error)
-	FindMyOrgs(req *IDRequest) (res []*OrgWithRole, err error)
-	GetOrgMembers(req *OrgRequest) (res *OrgMembersResponse, err error)
+	AddOrg(ctx context.Context, req *AddOrgRequest) (res *OrgResponse, err error)
+	FindMyOrgs(ctx context.Context, req *FindMyOrgsRequest) (res []*OrgWithRole, err error)
+	SetDefaultOrg(ctx context.Context, req *SetDefaultOrgRequest) (*DefaultOrgResponse, error)
+	GetOrgMembers(ctx context.Context, req *GetOrgMembersRequest) (res *OrgMembersResponse, err error)
+	GetSettings(ctx context.Context, req *OrgRequest) (*OrgSettingsResponse, error)
+	UpdateSettings(ctx context.Context, req *UpdateOrgSettingsRequest) (*OrgSettingsResponse, error)
+	SetParent(ctx context.Context, req *SetParentRequest) (*OrgResponse, error)
+	GetChildren(ctx context.Context, req *OrgChildrenRequest) (*OrgChildrenResponse, error)
 }
 
-func NewOrgService(db *gorm.DB, logger log.AllLogger) OrgAPI {
-	return &orgApi{db: db, logger: logger}
+// NewOrgService creates the org service. Avatar URLs in member listings are
+// signed by blobs and stay valid for urlTTL.
+func NewOrgService(db *gorm.DB, blobs storage.BlobStore, urlTTL time.Duration, logger *slog.Logger) OrgAPI {
+	return &orgApi{db: db, blobs: blobs, urlTTL: urlTTL, logger: logger, validate: helper.NewValidator(db)}
 }
 
 
@@ -37,105 +70,206 @@ func NewOrgService(db *gorm.DB, logger log.AllLogger) OrgAPI {
 // @Param			AddOrgRequest					body		AddOrgRequest	true	"AddOrgRequest"
 // @Success			200								{object}	OrgResponse
 // @Router           /api/orgs                        [POST]	
-func (s *orgApi) AddOrg(req *AddOrgRequest) (res *OrgResponse, err error) {
+func (s *orgApi) AddOrg(ctx context.Context, req *AddOrgRequest) (res *OrgResponse, err error) {
+	db := s.db.WithContext(ctx)
 	if req.UserID == 0 {
-		return nil, fmt.Errorf("user id is required")
-	}
-	if req.Name == "" {
-		return nil, fmt.Errorf("name is required")
+		return nil, helper.Unauthorized("unauthorized", "user id is required")
 	}
-	if req.Size == "" {
-		return nil, fmt.Errorf("size is required")
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
 	var user User
 
-	if err := s.db.First(&user, req.UserID).Error; err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+	if err := db.First(&user, req.UserID).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
 	}
 
-	var org Org
-	orgSlug := regexp.MustCompile(`[^a-zA-Z0-9]+`).ReplaceAllString(req.Name, "")
-	orgSlug = strings.ToLower(orgSlug)
-	orgSlug = strings.ReplaceAll(strings.TrimSpace(orgSlug), " ", "-")
-	s.db.Where("slug = ?", orgSlug).First(&org)
-	if org.ID != 0 {
-		return nil, fmt.Errorf("org slug already exists")
-	}
-
-	s.db.Where("name = ?", req.Name).First(&org)
-	if org.ID != 0 {
-		return nil, fmt.Errorf("org name already exists")
+	orgSlug := req.Slug
+	if orgSlug == "" {
+		orgSlug = regexp.MustCompile(`[^a-zA-Z0-9]+`).ReplaceAllString(req.Name, "")
+		orgSlug = strings.ToLower(orgSlug)
+		orgSlug = strings.ReplaceAll(strings.TrimSpace(orgSlug), " ", "-")
 	}
-
-	newOrg := &Org{
-		Name: req.Name,
-		Size: req.Size,
-		Slug: orgSlug,
+	if orgSlug == "" {
+		return nil, helper.FieldInvalid("name", "must contain at least one letter or digit")
 	}
 
-	result := s.db.Create(&newOrg)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to create org: %w", result.Error)
+	// Only owners and admins of the parent org can add orgs below it
+	if req.ParentID != nil {
+		adminOf, err := middleware.AdministeringOrg(ctx, s.db, *req.ParentID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if adminOf == 0 {
+			return nil, helper.Forbidden("permission_denied", "only owners and admins of the parent org can add orgs to it")
+		}
 	}
 
 	var ownerRole Role
-	if err := s.db.Where("name = ?", helper.OwnerRoleName).First(&ownerRole).Error; err != nil {
+	if err := db.Where("name = ?", helper.OwnerRoleName).First(&ownerRole).Error; err != nil {
 		return nil, fmt.Errorf("failed to get owner role: %w", err)
 	}
 
-	var userOrgRole UserOrgRole
-	userOrgRole.OrgID = newOrg.ID
-	userOrgRole.UserID = user.ID
-	userOrgRole.RoleID = int(ownerRole.ID)
-	userOrgRole.Status = "active"
-	result = s.db.Table(UserOrgRoleTableName).Create(&userOrgRole)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to create user org role: %w", result.Error)
+	newOrg := &Org{
+		Name:     req.Name,
+		Size:     req.Size,
+		Slug:     orgSlug,
+		ParentID: req.ParentID,
+	}
+
+	// The unique indexes on orgs.slug and orgs.name are what actually guard
+	// against duplicates; two concurrent requests both pass any pre-check.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newOrg).Error; err != nil {
+			if constraint, ok := helper.UniqueViolation(err); ok {
+				if strings.Contains(constraint, "name") {
+					return helper.Conflict("org_name_taken", "org name already exists")
+				}
+				return helper.Conflict("org_slug_taken", "org slug already exists")
+			}
+			return fmt.Errorf("failed to create org: %w", err)
+		}
+
+		userOrgRole := UserOrgRole{
+			OrgID:  newOrg.ID,
+			UserID: user.ID,
+			RoleID: int(ownerRole.ID),
+			Status: memberStatusActive,
+		}
+		if err := tx.Table(UserOrgRoleTableName).Create(&userOrgRole).Error; err != nil {
+			return fmt.Errorf("failed to create user org role: %w", err)
+		}
+
+		settings := DefaultSettings(newOrg.ID)
+		settings.RequireVerifiedEmail = req.RequireVerifiedEmail
+		if err := tx.Create(&settings).Error; err != nil {
+			return fmt.Errorf("failed to create org settings: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &OrgResponse{
-		ID:   newOrg.ID,
-		Name: newOrg.Name,
-		Slug: newOrg.Slug,
+		ID:                   newOrg.ID,
+		Name:                 newOrg.Name,
+		Slug:                 newOrg.Slug,
+		ParentID:             newOrg.ParentID,
+		RequireVerifiedEmail: req.RequireVerifiedEmail,
 	}, nil
 }
 
 // @Summary      	FindMyOrgs
-// @Description		Validates user is, will query DB the orgs that current user is linked to and then returns them in JSON.
+// @Description		Lists the orgs the current user belongs to for the org switcher, most recently accessed first, with the user's role and membership status. Only active memberships are listed unless status says otherwise. Deleted orgs are left out.
 // @Tags			Orgs
 // @Produce			json
 // @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			status							query		string			false	"active (default), inactive, invited, pending, rejected or all"
 // @Success			200								{array}	OrgWithRole
 // @Router			/api/orgs/me			[GET]
-func (s *orgApi) FindMyOrgs(req *IDRequest) (res []*OrgWithRole, err error) {
+func (s *orgApi) FindMyOrgs(ctx context.Context, req *FindMyOrgsRequest) (res []*OrgWithRole, err error) {
+	db := s.db.WithContext(ctx)
 	if req.UserID == 0 {
-		return nil, fmt.Errorf("user id is required")
+		return nil, helper.Unauthorized("unauthorized", "user id is required")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	query := db.Table(OrgTableName).
+		Select("orgs.id AS org_id, orgs.name, orgs.slug, user_org_roles.role_id, COALESCE(roles.name, '') AS role_name, "+
+			"user_org_roles.status, user_org_roles.user_id, user_org_roles.last_accessed_at, "+
+			"(SELECT COUNT(*) FROM user_org_roles members WHERE members.org_id = orgs.id AND members.status = ?) AS member_count", memberStatusActive).
+		Joins("JOIN user_org_roles ON user_org_roles.org_id = orgs.id").
+		Joins("LEFT JOIN roles ON roles.id = user_org_roles.role_id").
+		Where("user_org_roles.user_id = ? AND orgs.deleted_at IS NULL", req.UserID)
+	switch req.Status {
+	case "all":
+	case "":
+		query = query.Where("user_org_roles.status = ?", memberStatusActive)
+	default:
+		query = query.Where("user_org_roles.status = ?", req.Status)
 	}
 
-	rows, err := s.db.Table(OrgTableName).
-	Select("orgs.id", "orgs.name", "orgs.slug", "user_org_roles.role_id", "user_org_roles.user_id").
-	Joins("Left JOIN user_org_roles on user_org_roles.org_id = orgs.id").
-	Where("user_org_roles.user_id = ?", req.UserID).Rows()
+	orgRoles := []*OrgWithRole{}
+	err = query.Order("user_org_roles.last_accessed_at DESC NULLS LAST, lower(orgs.name), orgs.id").Scan(&orgRoles).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to query orgs: %w", err)
 	}
-	defer rows.Close()
 
-	var orgRoles []*OrgWithRole
+	var user struct{ DefaultOrgID *int }
+	if err := db.Table(UserTableName).Select("default_org_id").Where("id = ?", req.UserID).Scan(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get default org: %w", err)
+	}
+	markDefault(orgRoles, user.DefaultOrgID)
+
+	return orgRoles, nil
+}
+
+// @Summary      	SetDefaultOrg
+// @Description		Pins the org the UI opens first for the current user, or unpins it with orgId 0. The user must be an active member of the org.
+// @Tags			Orgs
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			SetDefaultOrgRequest			body		SetDefaultOrgRequest	true	"SetDefaultOrgRequest"
+// @Success			200								{object}	DefaultOrgResponse
+// @Router			/api/orgs/me/default			[PUT]
+func (s *orgApi) SetDefaultOrg(ctx context.Context, req *SetDefaultOrgRequest) (*DefaultOrgResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.UserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "user id is required")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
 
-	for rows.Next() {
-		onr := &OrgWithRole{}
-		err := rows.Scan(&onr.OrgID, &onr.Name, &onr.Slug, &onr.RoleID, &onr.UserID)
+	var orgID *int
+	if *req.OrgID != 0 {
+		var count int64
+		err := db.Table(UserOrgRoleTableName).
+			Joins("JOIN orgs ON orgs.id = user_org_roles.org_id AND orgs.deleted_at IS NULL").
+			Where("user_org_roles.user_id = ? AND user_org_roles.org_id = ? AND user_org_roles.status = ?", req.UserID, *req.OrgID, memberStatusActive).
+			Count(&count).Error
 		if err != nil {
-			fmt.Println("error scanning row", err)
+			return nil, err
 		}
-		orgRoles = append(orgRoles, onr)
+		if count == 0 {
+			return nil, helper.FieldInvalid("orgId", "must be an org you are an active member of")
+		}
+		orgID = req.OrgID
 	}
 
-	return orgRoles, nil
+	if err := db.Table(UserTableName).Where("id = ?", req.UserID).Update("default_org_id", orgID).Error; err != nil {
+		return nil, fmt.Errorf("failed to set default org: %w", err)
+	}
+
+	return &DefaultOrgResponse{OrgID: orgID}, nil
 }
 
+// markDefault flags the pinned org if it is listed as an active membership,
+// and otherwise the first active one, the org accessed last.
+func markDefault(orgRoles []*OrgWithRole, pinnedOrgID *int) {
+	first := -1
+	for i, o := range orgRoles {
+		if o.Status != memberStatusActive {
+			continue
+		}
+		if pinnedOrgID != nil && o.OrgID == *pinnedOrgID {
+			o.IsDefault = true
+			return
+		}
+		if first == -1 {
+			first = i
+		}
+	}
+	if first != -1 {
+		orgRoles[first].IsDefault = true
+	}
+}
 
 // @Summary      	GetOrgMembers
 // @Description		Validates user is, will query DB the orgs that current user is linked to and then returns them in JSON.
@@ -143,30 +277,51 @@ func (s *orgApi) FindMyOrgs(req *IDRequest) (res []*OrgWithRole, err error) {
 // @Produce			json
 // @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
 // @Param			orgId							path		int			true	"OrgID"
+// @Param			teamId							query		int			false	"Only members of this team"
+// @Param			includeSubteams					query		bool		false	"With teamId, also members of the teams nested inside it"
 // @Success			200								{object}	OrgMembersResponse
 // @Router			/api/o/{orgId}/members		[GET]
-func (s *orgApi) GetOrgMembers(req *OrgRequest) (*OrgMembersResponse, error) {
-	if req.UserID == 0 {
-		return nil, fmt.Errorf("user id is required")
+func (s *orgApi) GetOrgMembers(ctx context.Context, req *GetOrgMembersRequest) (*OrgMembersResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.UserID == 0 && req.APIKeyID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "user id is required")
 	}
 
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("org id is required")
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	query := db.Where("org_id = ?", req.OrgID)
+	if req.TeamID != 0 {
+		teamIDs, err := teams.SubtreeIDs(ctx, s.db, req.OrgID, req.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		// SubtreeIDs has confirmed the team belongs to the org
+		if !req.IncludeSubteams {
+			teamIDs = []int{req.TeamID}
+		}
+		query = query.Where("user_id IN (?)", db.Table(teams.TeamMemberTableName).Select("user_id").Where("team_id IN ?", teamIDs))
 	}
 
 	var userOrgRoles []UserOrgRole
-	if err := s.db.Where("org_id = ?", req.OrgID).Find(&userOrgRoles).Error; err != nil {
+	if err := query.Find(&userOrgRoles).Error; err != nil {
 		return nil, fmt.Errorf("failed to get user org roles: %w", err)
 	}
 
 	var orgMembers []OrgMembers
 	for _, uor := range userOrgRoles {
 		var user User
-		if err := s.db.First(&user, uor.UserID).Error; err != nil {
+		if err := db.First(&user, uor.UserID).Error; err != nil {
 			continue // Skip if user not found
 		}
 
 		if user.Username != nil {
+			avatarURLs, err := avatars.URLs(ctx, s.blobs, user.AvatarImgKey, s.urlTTL)
+			if err != nil {
+				return nil, err
+			}
+
 			orgMembers = append(orgMembers, OrgMembers{
 				UserOrgRole: UserOrgRoleResponse{
 					UserID: uor.UserID,
@@ -182,6 +337,7 @@ func (s *orgApi) GetOrgMembers(req *OrgRequest) (*OrgMembersResponse, error) {
 					LastName:     user.LastName,
 					Status:       user.Status,
 					AvatarImgKey: user.AvatarImgKey,
+					AvatarURLs:   avatarURLs,
 					Active:       user.Active,
 					Phone:        user.Phone,
 				},
@@ -205,4 +361,303 @@ func (s *orgApi) GetOrgMembers(req *OrgRequest) (*OrgMembersResponse, error) {
 	return &OrgMembersResponse{
 		OrgMembers: orgMembers,
 	}, nil
-}
\ No newline at end of file
+}
+
+// @Summary      	GetOrgSettings
+// @Description		Returns the branding and membership settings of the org. Orgs that never changed them get the defaults.
+// @Tags			Orgs
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"Org ID"
+// @Success			200								{object}	OrgSettingsResponse
+// @Router			/api/o/{orgId}/settings		[GET]
+func (s *orgApi) GetSettings(ctx context.Context, req *OrgRequest) (*OrgSettingsResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	settings, err := LoadSettings(db, req.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toSettingsResponse(settings), nil
+}
+
+// @Summary      	UpdateOrgSettings
+// @Description		Changes the branding and membership settings of the org. Fields left out of the request are not changed. Owners and admins only; every change is recorded in the audit log.
+// @Tags			Orgs
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string						true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int							true	"Org ID"
+// @Param			UpdateOrgSettingsRequest		body		UpdateOrgSettingsRequest	true	"UpdateOrgSettingsRequest"
+// @Success			200								{object}	OrgSettingsResponse
+// @Router			/api/o/{orgId}/settings		[PATCH]
+func (s *orgApi) UpdateSettings(ctx context.Context, req *UpdateOrgSettingsRequest) (*OrgSettingsResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "org settings can only be changed by users")
+	}
+	if req.CurrentRoleID != 1 && req.CurrentRoleID != 2 {
+		return nil, helper.Forbidden("permission_denied", "only owners and admins can change org settings")
+	}
+	if req.LogoURL != nil {
+		*req.LogoURL = strings.TrimSpace(*req.LogoURL)
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var settings OrgSettings
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		settings, err = LoadSettings(tx.Clauses(clause.Locking{Strength: "UPDATE"}), req.OrgID)
+		if err != nil {
+			return err
+		}
+
+		changes := map[string]interface{}{}
+		setString := func(name string, field *string, value *string) {
+			if value != nil && *value != *field {
+				changes[name] = audit.Change{Old: *field, New: *value}
+				*field = *value
+			}
+		}
+		// 0 clears an optional number.
+		setOptionalInt := func(name string, field **int, value *int) {
+			if value == nil {
+				return
+			}
+			var next *int
+			if *value != 0 {
+				next = value
+			}
+			if (*field == nil) != (next == nil) || (next != nil && **field != *next) {
+				changes[name] = audit.Change{Old: *field, New: next}
+				*field = next
+			}
+		}
+
+		setString("logoUrl", &settings.LogoURL, req.LogoURL)
+		setString("primaryColor", &settings.PrimaryColor, req.PrimaryColor)
+		setString("defaultLocale", &settings.DefaultLocale, req.DefaultLocale)
+		setString("timezone", &settings.Timezone, req.Timezone)
+		setString("joinPolicy", &settings.JoinPolicy, req.JoinPolicy)
+		setOptionalInt("defaultRoleId", &settings.DefaultRoleID, req.DefaultRoleID)
+		setOptionalInt("invitationTtlHours", &settings.InvitationTTLHours, req.InvitationTTLHours)
+		if req.RequireVerifiedEmail != nil && *req.RequireVerifiedEmail != settings.RequireVerifiedEmail {
+			changes["requireVerifiedEmail"] = audit.Change{Old: settings.RequireVerifiedEmail, New: *req.RequireVerifiedEmail}
+			settings.RequireVerifiedEmail = *req.RequireVerifiedEmail
+		}
+		if req.RequireMFAForAdmins != nil && *req.RequireMFAForAdmins != settings.RequireMFAForAdmins {
+			if *req.RequireMFAForAdmins {
+				enabled, err := middleware.HasMFA(ctx, tx, req.CurrentUserID)
+				if err != nil {
+					return err
+				}
+				if !enabled {
+					return helper.Forbidden("mfa_enrollment_required", "set up multi-factor authentication before requiring it for owners and admins")
+				}
+			}
+			changes["requireMfaForAdmins"] = audit.Change{Old: settings.RequireMFAForAdmins, New: *req.RequireMFAForAdmins}
+			settings.RequireMFAForAdmins = *req.RequireMFAForAdmins
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		settings.UpdatedBy = &req.CurrentUserID
+		settings.UpdatedAt = &now
+		if err := tx.Save(&settings).Error; err != nil {
+			return err
+		}
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionSettingsUpdated,
+			TargetType:  "org",
+			TargetID:    strconv.Itoa(req.OrgID),
+			Details:     changes,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSettingsResponse(settings), nil
+}
+
+// @Summary      	SetOrgParent
+// @Description		Moves the org below another org, or detaches it with parentId 0. Owners and admins of the new parent org then administer this org. Requires owner or admin in this org and, when attaching, in the new parent.
+// @Tags			Orgs
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string				true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int					true	"Org ID"
+// @Param			SetParentRequest				body		SetParentRequest	true	"SetParentRequest"
+// @Success			200								{object}	OrgResponse
+// @Router			/api/o/{orgId}/parent		[PUT]
+func (s *orgApi) SetParent(ctx context.Context, req *SetParentRequest) (*OrgResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "org parents can only be changed by users")
+	}
+	if req.CurrentRoleID != 1 && req.CurrentRoleID != 2 {
+		return nil, helper.Forbidden("permission_denied", "only owners and admins can move an org")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var org Org
+	var settings OrgSettings
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", orgTreeLockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND deleted_at IS NULL", req.OrgID).First(&org).Error; err != nil {
+			return helper.NotFoundIfMissing(err, "org_not_found", "org not found")
+		}
+		var err error
+		if settings, err = LoadSettings(tx, req.OrgID); err != nil {
+			return err
+		}
+
+		var parentID *int
+		if *req.ParentID != 0 {
+			parentID = req.ParentID
+		}
+		if (org.ParentID == nil) == (parentID == nil) && (parentID == nil || *org.ParentID == *parentID) {
+			return nil
+		}
+
+		if parentID != nil {
+			below, err := descendants(tx, org.ID)
+			if err != nil {
+				return err
+			}
+			if *parentID == org.ID || slices.ContainsFunc(below, func(d Org) bool { return d.ID == *parentID }) {
+				return helper.FieldInvalid("parentId", "cannot be the org itself or one of the orgs below it")
+			}
+			adminOf, err := middleware.AdministeringOrg(ctx, tx, *parentID, req.CurrentUserID)
+			if err != nil {
+				return err
+			}
+			if adminOf == 0 {
+				return helper.Forbidden("permission_denied", "only owners and admins of the new parent org can move orgs under it")
+			}
+		}
+
+		change := audit.Change{Old: org.ParentID, New: parentID}
+		if err := tx.Model(&org).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		org.ParentID = parentID
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionParentChanged,
+			TargetType:  "org",
+			TargetID:    strconv.Itoa(req.OrgID),
+			Details:     map[string]interface{}{"parentId": change},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrgResponse{
+		ID:                   org.ID,
+		Name:                 org.Name,
+		Size:                 org.Size,
+		Slug:                 org.Slug,
+		ParentID:             org.ParentID,
+		RequireVerifiedEmail: settings.RequireVerifiedEmail,
+	}, nil
+}
+
+// @Summary      	GetOrgChildren
+// @Description		Returns the tree of orgs below the org. Owners and admins of the org administer all of them. Owners and admins only.
+// @Tags			Orgs
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"Org ID"
+// @Success			200								{object}	OrgChildrenResponse
+// @Router			/api/o/{orgId}/children		[GET]
+func (s *orgApi) GetChildren(ctx context.Context, req *OrgChildrenRequest) (*OrgChildrenResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "child orgs can only be listed by users")
+	}
+	if req.CurrentRoleID != 1 && req.CurrentRoleID != 2 {
+		return nil, helper.Forbidden("permission_denied", "only owners and admins can list child orgs")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	below, err := descendants(db, req.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child orgs: %w", err)
+	}
+
+	byParent := map[int][]Org{}
+	for _, o := range below {
+		byParent[*o.ParentID] = append(byParent[*o.ParentID], o)
+	}
+	var build func(parentID int) []OrgTreeNode
+	build = func(parentID int) []OrgTreeNode {
+		nodes := make([]OrgTreeNode, 0, len(byParent[parentID]))
+		for _, o := range byParent[parentID] {
+			nodes = append(nodes, OrgTreeNode{
+				ID:       o.ID,
+				Name:     o.Name,
+				Slug:     o.Slug,
+				ParentID: o.ParentID,
+				Children: build(o.ID),
+			})
+		}
+		return nodes
+	}
+
+	return &OrgChildrenResponse{Children: build(req.OrgID)}, nil
+}
+
+// descendants returns the orgs below orgID that are not deleted, ordered by
+// name. Orgs below a deleted org are left out.
+func descendants(db *gorm.DB, orgID int) ([]Org, error) {
+	var orgs []Org
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, name, slug, parent_id FROM orgs WHERE parent_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT orgs.id, orgs.name, orgs.slug, orgs.parent_id FROM orgs JOIN tree ON orgs.parent_id = tree.id
+			WHERE orgs.deleted_at IS NULL
+		)
+		SELECT id, name, slug, parent_id FROM tree ORDER BY lower(name), id`, orgID).
+		Scan(&orgs).Error
+	return orgs, err
+}
+
+func toSettingsResponse(settings OrgSettings) *OrgSettingsResponse {
+	return &OrgSettingsResponse{
+		OrgID:                settings.OrgID,
+		LogoURL:              settings.LogoURL,
+		PrimaryColor:         settings.PrimaryColor,
+		DefaultLocale:        settings.DefaultLocale,
+		Timezone:             settings.Timezone,
+		DefaultRoleID:        settings.DefaultRoleID,
+		JoinPolicy:           settings.JoinPolicy,
+		InvitationTTLHours:   settings.InvitationTTLHours,
+		RequireVerifiedEmail: settings.RequireVerifiedEmail,
+		RequireMFAForAdmins:  settings.RequireMFAForAdmins,
+		UpdatedAt:            settings.UpdatedAt,
+	}
+}
@@ -1,26 +1,31 @@
 package org
 
 import (
-	"fmt"
+	"log/slog"
+	"org-service/helper"
 	"org-service/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 )
 
 type OrgHTTPTransport interface {
 	AddOrg(c *fiber.Ctx) error
 	FindMyOrgs(c *fiber.Ctx) error
+	SetDefaultOrg(c *fiber.Ctx) error
 	GetOrgMembers(c *fiber.Ctx) error
+	GetSettings(c *fiber.Ctx) error
+	UpdateSettings(c *fiber.Ctx) error
+	SetParent(c *fiber.Ctx) error
+	GetChildren(c *fiber.Ctx) error
 }
 
 type orgHttpTransport struct {
 	orgApi OrgAPI
-	logger log.AllLogger
+	logger *slog.Logger
 }
 
-func NewOrgHTTPTransport(orgApi OrgAPI, logger log.AllLogger) *orgHttpTransport {
+func NewOrgHTTPTransport(orgApi OrgAPI, logger *slog.Logger) *orgHttpTransport {
 	return &orgHttpTransport{orgApi: orgApi, logger: logger}
 }
 
@@ -29,61 +34,149 @@ func (s *orgHttpTransport) AddOrg(c *fiber.Ctx) error {
 	req := &AddOrgRequest{}
 	userId, err := middleware.CtxUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized")
+		return err
 	}
 
 	req.UserID = userId
 	if err := c.BodyParser(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+		return helper.ValidationError("invalid_body", "Invalid request body")
 	}
 
-	res, err := s.orgApi.AddOrg(req)
+	res, err := s.orgApi.AddOrg(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
 	}
 
 	return c.JSON(res)
 }
 
 func (s *orgHttpTransport) FindMyOrgs(c *fiber.Ctx) error {
-	req := &IDRequest{}
+	req := &FindMyOrgsRequest{}
 	userId, err := middleware.CtxUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized")
+		return err
 	}
 
 	req.UserID = userId
+	req.Status = c.Query("status")
 
-	res, err := s.orgApi.FindMyOrgs(req)
+	res, err := s.orgApi.FindMyOrgs(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
 	}
 
 	return c.JSON(res)
 }
 
-func (s *orgHttpTransport) GetOrgMembers(c *fiber.Ctx) error {
-	req := &OrgRequest{}
+func (s *orgHttpTransport) SetDefaultOrg(c *fiber.Ctx) error {
+	req := &SetDefaultOrgRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
 	userId, err := middleware.CtxUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized")
+		return err
+	}
+	req.UserID = userId
+
+	res, err := s.orgApi.SetDefaultOrg(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(res)
+}
+
+func (s *orgHttpTransport) GetOrgMembers(c *fiber.Ctx) error {
+	req := &GetOrgMembersRequest{}
+	if key := middleware.CtxAPIKey(c); key != nil {
+		req.APIKeyID = key.ID
+	}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil && req.APIKeyID == 0 {
+		return err
 	}
 
 	orgIdStr := c.Params("orgId")
-	fmt.Println("orgIdStr", orgIdStr)
 	orgId, err := strconv.Atoi(orgIdStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid org id")
+		return helper.FieldInvalid("orgId", "must be a number")
 	}
 
 	req.UserID = userId
 	req.OrgID = orgId
 
-	resp, err := s.orgApi.GetOrgMembers(req)
+	if teamIdStr := c.Query("teamId"); teamIdStr != "" {
+		if req.TeamID, err = strconv.Atoi(teamIdStr); err != nil {
+			return helper.FieldInvalid("teamId", "must be a number")
+		}
+		req.IncludeSubteams = c.QueryBool("includeSubteams")
+	}
+
+	resp, err := s.orgApi.GetOrgMembers(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *orgHttpTransport) GetSettings(c *fiber.Ctx) error {
+	req := &OrgRequest{}
+	req.OrgID = middleware.CtxOrgID(c)
+
+	resp, err := s.orgApi.GetSettings(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
 	}
 
 	return c.JSON(resp)
 }
 
+func (s *orgHttpTransport) UpdateSettings(c *fiber.Ctx) error {
+	req := &UpdateOrgSettingsRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.orgApi.UpdateSettings(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *orgHttpTransport) SetParent(c *fiber.Ctx) error {
+	req := &SetParentRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.orgApi.SetParent(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (s *orgHttpTransport) GetChildren(c *fiber.Ctx) error {
+	req := &OrgChildrenRequest{}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.orgApi.GetChildren(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"org-service/audit"
+	"org-service/middleware"
+)
+
+const platformAdminUsage = `usage: org-service platform-admin <command>
+
+commands:
+  grant <email>   make the user a platform superadmin
+  revoke <email>  remove the user's platform role
+  list            list platform superadmins`
+
+// Audit actions recorded by platform-admin commands. There is no acting user;
+// the change was made by whoever runs the service.
+const (
+	auditActionPlatformRoleGranted = "platform.role.granted"
+	auditActionPlatformRoleRevoked = "platform.role.revoked"
+)
+
+// runPlatformAdmin handles `org-service platform-admin ...`. Platform roles
+// are deliberately not grantable over the API.
+func runPlatformAdmin(conn *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(platformAdminUsage)
+	}
+
+	switch args[0] {
+	case "grant", "revoke":
+		if len(args) < 2 {
+			return errors.New(platformAdminUsage)
+		}
+		role, action := middleware.PlatformRoleSuperadmin, auditActionPlatformRoleGranted
+		if args[0] == "revoke" {
+			role, action = "", auditActionPlatformRoleRevoked
+		}
+		return setPlatformRole(conn, strings.TrimSpace(args[1]), role, action)
+	case "list":
+		return printPlatformAdmins(conn)
+	default:
+		return errors.New(platformAdminUsage)
+	}
+}
+
+func setPlatformRole(conn *gorm.DB, email, role, action string) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		var user struct {
+			ID           int
+			PlatformRole string
+		}
+		result := tx.Table("users").Select("id, platform_role").Where("lower(email) = lower(?)", email).Scan(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("no user with email %q", email)
+		}
+		if user.PlatformRole == role {
+			fmt.Printf("%s already has platform role %q\n", email, role)
+			return nil
+		}
+
+		if err := tx.Table("users").Where("id = ?", user.ID).Update("platform_role", role).Error; err != nil {
+			return err
+		}
+		err := audit.Record(tx, &audit.Event{
+			Action:     action,
+			TargetType: "user",
+			TargetID:   strconv.Itoa(user.ID),
+			Details:    map[string]interface{}{"platformRole": audit.Change{Old: user.PlatformRole, New: role}},
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s now has platform role %q\n", email, role)
+		return nil
+	})
+}
+
+func printPlatformAdmins(conn *gorm.DB) error {
+	var admins []struct {
+		ID           int
+		Email        string
+		PlatformRole string
+	}
+	err := conn.Table("users").Select("id, email, platform_role").Where("platform_role <> ''").Order("email").Scan(&admins).Error
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tROLE")
+	for _, a := range admins {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", a.ID, a.Email, a.PlatformRole)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Expired windows are dropped at most this often.
+const sweepInterval = time.Minute
+
+type counter struct {
+	count   int64
+	resetAt time.Time
+}
+
+// MemoryStore is a process-local Store. Counters are lost on restart and not
+// shared between replicas.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryStore) Incr(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = &counter{resetAt: now.Add(window)}
+		m.counters[key] = c
+	}
+	c.count++
+	return c.count, c.resetAt.Sub(now), nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, key string) (int64, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		return 0, 0, nil
+	}
+	return c.count, c.resetAt.Sub(now), nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.counters, key)
+	return nil
+}
+
+// sweep drops expired counters so keys for one-off IPs do not pile up.
+// The caller holds m.mu.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, c := range m.counters {
+		if !now.Before(c.resetAt) {
+			delete(m.counters, key)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a MemoryStore clock moved by hand.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryStoreWindow(t *testing.T) {
+	ctx := context.Background()
+	const window = time.Minute
+
+	type step struct {
+		advance     time.Duration
+		wantCount   int64
+		wantResetIn time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"counts within the window", []step{
+			{0, 1, time.Minute},
+			{10 * time.Second, 2, 50 * time.Second},
+			{49 * time.Second, 3, time.Second},
+		}},
+		{"starts over once the window has passed", []step{
+			{0, 1, time.Minute},
+			{30 * time.Second, 2, 30 * time.Second},
+			{30 * time.Second, 1, time.Minute},
+			{time.Second, 2, 59 * time.Second},
+		}},
+		{"does not slide the window", []step{
+			{0, 1, time.Minute},
+			{59 * time.Second, 2, time.Second},
+			{time.Second, 1, time.Minute},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, clock := newTestStore()
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				count, resetIn, err := store.Incr(ctx, "k", window)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != s.wantCount || resetIn != s.wantResetIn {
+					t.Fatalf("step %d: got %d, %v, want %d, %v", i, count, resetIn, s.wantCount, s.wantResetIn)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreGetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+
+	if count, resetIn, _ := store.Get(ctx, "k"); count != 0 || resetIn != 0 {
+		t.Fatalf("unset key: got %d, %v", count, resetIn)
+	}
+
+	store.Incr(ctx, "k", time.Minute)
+	store.Incr(ctx, "k", time.Minute)
+	clock.Advance(20 * time.Second)
+	if count, resetIn, _ := store.Get(ctx, "k"); count != 2 || resetIn != 40*time.Second {
+		t.Fatalf("got %d, %v, want 2, 40s", count, resetIn)
+	}
+
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _, _ := store.Get(ctx, "k"); count != 0 {
+		t.Fatalf("deleted key: got %d", count)
+	}
+
+	store.Incr(ctx, "k", time.Minute)
+	clock.Advance(time.Minute)
+	if count, resetIn, _ := store.Get(ctx, "k"); count != 0 || resetIn != 0 {
+		t.Fatalf("expired key: got %d, %v", count, resetIn)
+	}
+}
+
+// The lockout middleware counts failures in one window and, past the limit,
+// sets a lock key that lasts the lockout duration.
+func TestMemoryStoreLockoutExpiry(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+	const (
+		maxFailures = 3
+		window      = 15 * time.Minute
+		duration    = 10 * time.Minute
+	)
+
+	for i := 1; i <= maxFailures; i++ {
+		failures, _, _ := store.Incr(ctx, "failures", window)
+		if failures == maxFailures {
+			store.Incr(ctx, "lockout", duration)
+			store.Delete(ctx, "failures")
+		}
+		clock.Advance(time.Minute)
+	}
+
+	if locked, resetIn, _ := store.Get(ctx, "lockout"); locked == 0 || resetIn != duration-time.Minute {
+		t.Fatalf("got %d, %v, want locked for another %v", locked, resetIn, duration-time.Minute)
+	}
+	if failures, _, _ := store.Get(ctx, "failures"); failures != 0 {
+		t.Fatalf("failures were not cleared: %d", failures)
+	}
+
+	clock.Advance(duration - time.Minute - time.Second)
+	if locked, resetIn, _ := store.Get(ctx, "lockout"); locked == 0 || resetIn != time.Second {
+		t.Fatalf("got %d, %v, want locked for another second", locked, resetIn)
+	}
+	clock.Advance(time.Second)
+	if locked, _, _ := store.Get(ctx, "lockout"); locked != 0 {
+		t.Fatal("still locked after the lockout duration")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+
+	store.Incr(ctx, "short", time.Second)
+	store.Incr(ctx, "long", time.Hour)
+	clock.Advance(sweepInterval)
+	store.Incr(ctx, "other", time.Hour)
+
+	if _, ok := store.counters["short"]; ok {
+		t.Error("expired counter was not swept")
+	}
+	if _, ok := store.counters["long"]; !ok {
+		t.Error("live counter was swept")
+	}
+}
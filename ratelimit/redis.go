@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RedisClient is the subset of a Redis client that RedisStore needs.
+// RESPClient implements it; with go-redis it is a thin adapter:
+//
+//	func (a adapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+//		return a.Client.Eval(ctx, script, keys, args...).Result()
+//	}
+//
+// Any server speaking the Redis protocol with Lua scripting (Redis, Valkey,
+// KeyDB, Dragonfly) works.
+type RedisClient interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// INCR and PEXPIRE run as one script so a crash between them cannot leave a
+// counter without an expiry.
+const incrScript = `
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}`
+
+const getScript = `
+local count = redis.call('GET', KEYS[1])
+if not count then
+	return {0, 0}
+end
+return {tonumber(count), redis.call('PTTL', KEYS[1])}`
+
+const deleteScript = `return redis.call('DEL', KEYS[1])`
+
+// RedisStore is a Store shared by every replica pointing at the same server.
+type RedisStore struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisStore stores counters under prefix (e.g. "org-service:ratelimit:").
+func NewRedisStore(client RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (r *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	res, err := r.client.Eval(ctx, incrScript, []string{r.prefix + key}, window.Milliseconds())
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseCountTTL(res)
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	res, err := r.client.Eval(ctx, getScript, []string{r.prefix + key})
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseCountTTL(res)
+}
+
+func (r *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := r.client.Eval(ctx, deleteScript, []string{r.prefix + key})
+	return err
+}
+
+func parseCountTTL(res interface{}) (int64, time.Duration, error) {
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	count, ok1 := values[0].(int64)
+	ttl, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, 0, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return count, time.Duration(ttl) * time.Millisecond, nil
+}
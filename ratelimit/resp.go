@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Commands without a context deadline give up after this long.
+const respTimeout = 2 * time.Second
+
+// RedisError is an error reply from the server, e.g. a failing script. The
+// connection stays usable.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RESPClient is a minimal RedisClient speaking the Redis protocol over TCP,
+// enough for the EVAL calls RedisStore makes. Idle connections are kept for
+// reuse.
+type RESPClient struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *respConn
+}
+
+type respConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRESPClient connects lazily to the server at addr, authenticating with
+// password and selecting db when they are set.
+func NewRESPClient(addr, password string, db int) *RESPClient {
+	return &RESPClient{addr: addr, password: password, db: db, idle: make(chan *respConn, 16)}
+}
+
+func (c *RESPClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, "EVAL", script, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+	for _, arg := range args {
+		cmd = append(cmd, fmt.Sprint(arg))
+	}
+
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(ctx, cmd...)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	return reply, err
+}
+
+// Close closes the idle connections.
+func (c *RESPClient) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RESPClient) conn(ctx context.Context) (*respConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	var d net.Dialer
+	if _, ok := ctx.Deadline(); !ok {
+		d.Timeout = respTimeout
+	}
+	nc, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &respConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.password != "" {
+		if _, err := conn.do(ctx, "AUTH", c.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := conn.do(ctx, "SELECT", strconv.Itoa(c.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *RESPClient) release(conn *respConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (conn *respConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(respTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+// readReply decodes one RESP2 reply. Integers are int64, bulk and simple
+// strings are string, arrays are []interface{} and nil replies are nil.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, RedisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    interface{}
+		wantErr bool
+	}{
+		{"simple string", "+OK\r\n", "OK", false},
+		{"error", "-ERR wrong\r\n", nil, true},
+		{"integer", ":42\r\n", int64(42), false},
+		{"negative integer", ":-2\r\n", int64(-2), false},
+		{"bulk string", "$5\r\nhello\r\n", "hello", false},
+		{"nil bulk string", "$-1\r\n", nil, false},
+		{"array", "*2\r\n:3\r\n:59000\r\n", []interface{}{int64(3), int64(59000)}, false},
+		{"nested array", "*2\r\n*1\r\n+a\r\n$1\r\nb\r\n", []interface{}{[]interface{}{"a"}, "b"}, false},
+		{"missing CR", "+OK\n", nil, true},
+		{"unknown type", "!oops\r\n", nil, true},
+		{"truncated", "$5\r\nhel", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.in)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisStoreOverRESP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	commands := make(chan []interface{}, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		replies := []string{
+			"+OK\r\n",                // AUTH
+			"*2\r\n:1\r\n:60000\r\n", // Incr
+			"-ERR script failed\r\n", // Get
+			":1\r\n",                 // Delete
+		}
+		for _, reply := range replies {
+			cmd, err := readReply(r)
+			if err != nil {
+				return
+			}
+			commands <- cmd.([]interface{})
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	client := NewRESPClient(ln.Addr().String(), "secret", 0)
+	defer client.Close()
+	store := NewRedisStore(client, "test:")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, resetIn, err := store.Incr(ctx, "k", time.Minute)
+	if err != nil || count != 1 || resetIn != time.Minute {
+		t.Fatalf("Incr: got %d, %v, %v", count, resetIn, err)
+	}
+	if auth := <-commands; !reflect.DeepEqual(auth, []interface{}{"AUTH", "secret"}) {
+		t.Fatalf("first command %v, want AUTH", auth)
+	}
+	if eval := <-commands; eval[0] != "EVAL" || eval[2] != "1" || eval[3] != "test:k" || eval[4] != "60000" {
+		t.Fatalf("Incr sent %v", eval)
+	}
+
+	// An error reply fails the call but keeps the connection
+	var redisErr RedisError
+	if _, _, err := store.Get(ctx, "k"); !errors.As(err, &redisErr) {
+		t.Fatalf("Get: got %v, want a RedisError", err)
+	}
+	<-commands
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps fixed-window counters shared by the rate limiter and the
+// failed-attempt lockout. MemoryStore is enough for a single instance;
+// RedisStore shares counters between replicas.
+type Store interface {
+	// Incr adds one to key, starting a new window of length window if key
+	// is absent or expired. It returns the new count and the time left until
+	// the window resets.
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, resetIn time.Duration, err error)
+	// Get returns the count and time left for key, or 0, 0 if it is unset.
+	Get(ctx context.Context, key string) (count int64, resetIn time.Duration, err error)
+	Delete(ctx context.Context, key string) error
+}
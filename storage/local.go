@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"org-service/config"
+	"org-service/helper"
+	"org-service/tracing"
+)
+
+// LocalStore keeps blobs as files under a directory. Its signed URLs point at
+// Handler, which must be mounted at /api/blobs.
+type LocalStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+func NewLocalStore(cfg config.LocalStorageConfig) (*LocalStore, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating storage dir: %w", err)
+	}
+
+	key := []byte(cfg.SigningKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStore{
+		dir:        cfg.Dir,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		signingKey: key,
+	}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body []byte, contentType string) (err error) {
+	_, span := tracing.Start(ctx, "blob.Put")
+	defer func() { tracing.End(span, err) }()
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial blob.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) (err error) {
+	_, span := tracing.Start(ctx, "blob.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return s.baseURL + "/api/blobs/" + key + "?expires=" + expires + "&sig=" + s.sign(key, expires), nil
+}
+
+// Handler serves blobs for URLs made by SignedURL.
+func (s *LocalStore) Handler(c *fiber.Ctx) error {
+	key := c.Params("*")
+	expires := c.Query("expires")
+	sig := c.Query("sig")
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt ||
+		!hmac.Equal([]byte(sig), []byte(s.sign(key, expires))) {
+		return helper.Forbidden("invalid_signature", "URL signature is invalid or expired")
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return helper.NotFound("blob_not_found", "file not found")
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return helper.NotFound("blob_not_found", "file not found")
+		}
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(max(0, expiresAt-time.Now().Unix()), 10))
+	return c.SendFile(path)
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps key to a file under dir, rejecting keys that would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"org-service/config"
+)
+
+// BlobStore keeps uploaded files by key. Keys are slash-separated paths
+// chosen by the caller, e.g. avatars/12/abc/256.png.
+type BlobStore interface {
+	// Put stores body under key, replacing any blob already there.
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	// Delete removes the blob at key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL anyone can fetch the blob from until ttl has
+	// passed.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New returns the BlobStore selected by cfg.Backend.
+func New(ctx context.Context, cfg config.StorageConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocalStore(cfg.Local)
+	case "s3":
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
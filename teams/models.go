@@ -0,0 +1,88 @@
+package teams
+
+import "time"
+
+type CreateTeamRequest struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	Description   string `json:"description" validate:"max=500"`
+	ParentID      *int   `json:"parentId" validate:"omitempty,min=1"`
+	OrgID         int    `json:"-" validate:"required"`
+	CurrentUserID int    `json:"-"`
+	CurrentRoleID int    `json:"-"`
+}
+
+type OrgRequest struct {
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+type TeamRequest struct {
+	TeamID        int `json:"-" validate:"required"`
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+// UpdateTeamRequest changes only the fields that are present. A parentId of
+// 0 moves the team to the top level.
+type UpdateTeamRequest struct {
+	TeamID        int     `json:"-" validate:"required"`
+	OrgID         int     `json:"-" validate:"required"`
+	CurrentUserID int     `json:"-"`
+	CurrentRoleID int     `json:"-"`
+	Name          *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description   *string `json:"description" validate:"omitempty,max=500"`
+	ParentID      *int    `json:"parentId" validate:"omitempty,min=0"`
+}
+
+type SetTeamMemberRequest struct {
+	Role          string `json:"role" validate:"required,oneof=lead member"`
+	TeamID        int    `json:"-" validate:"required"`
+	UserID        int    `json:"-" validate:"required"`
+	OrgID         int    `json:"-" validate:"required"`
+	CurrentUserID int    `json:"-"`
+	CurrentRoleID int    `json:"-"`
+}
+
+type TeamMemberRequest struct {
+	TeamID        int `json:"-" validate:"required"`
+	UserID        int `json:"-" validate:"required"`
+	OrgID         int `json:"-" validate:"required"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
+}
+
+type TeamResponse struct {
+	ID          int        `json:"id"`
+	ParentID    *int       `json:"parentId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	MemberCount int        `json:"memberCount"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+type ListTeamsResponse struct {
+	Teams []TeamResponse `json:"teams"`
+}
+
+type TeamMemberResponse struct {
+	UserID    int       `json:"userId"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TeamDetailResponse lists the direct members of the team; members of its
+// sub-teams are listed with those.
+type TeamDetailResponse struct {
+	TeamResponse
+	Members []TeamMemberResponse `json:"members"`
+}
+
+type StatusResponse struct {
+	Status bool `json:"status"`
+}
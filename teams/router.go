@@ -0,0 +1,18 @@
+package teams
+
+import (
+	"org-service/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterRoutes(orgRouter fiber.Router, teamHttpTransport TeamHTTPTransport, rbac middleware.RBAC) {
+	teamRouter := orgRouter.Group("/teams")
+	teamRouter.Post("/", teamHttpTransport.CreateTeam)
+	teamRouter.Get("/", rbac.RequireScope(middleware.ScopeTeamsRead), teamHttpTransport.ListTeams)
+	teamRouter.Get("/:teamId", rbac.RequireScope(middleware.ScopeTeamsRead), teamHttpTransport.GetTeam)
+	teamRouter.Patch("/:teamId", rbac.RequireTeamRole(middleware.TeamRoleLead), teamHttpTransport.UpdateTeam)
+	teamRouter.Delete("/:teamId", teamHttpTransport.DeleteTeam)
+	teamRouter.Put("/:teamId/members/:userId", rbac.RequireTeamRole(middleware.TeamRoleLead), teamHttpTransport.SetTeamMember)
+	teamRouter.Delete("/:teamId/members/:userId", rbac.RequireTeamRole(middleware.TeamRoleLead), teamHttpTransport.RemoveTeamMember)
+}
@@ -0,0 +1,37 @@
+package teams
+
+import "time"
+
+const (
+	TeamTableName       = "teams"
+	TeamMemberTableName = "team_members"
+)
+
+// Team groups members of an org. ParentID nests it inside another team of
+// the same org.
+type Team struct {
+	ID          int `gorm:"primaryKey"`
+	OrgID       int `gorm:"not null"`
+	ParentID    *int
+	Name        string `gorm:"not null"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time `gorm:"autoUpdateTime:false"`
+}
+
+func (Team) TableName() string {
+	return TeamTableName
+}
+
+// TeamMember gives a user a team-level role, middleware.TeamRoleLead or
+// middleware.TeamRoleMember.
+type TeamMember struct {
+	TeamID    int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"primaryKey"`
+	Role      string `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (TeamMember) TableName() string {
+	return TeamMemberTableName
+}
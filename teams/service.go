@@ -0,0 +1,636 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"org-service/audit"
+	"org-service/config"
+	"org-service/helper"
+	"org-service/middleware"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Audit actions recorded by the team service.
+const (
+	AuditActionTeamCreated       = "team.created"
+	AuditActionTeamUpdated       = "team.updated"
+	AuditActionTeamDeleted       = "team.deleted"
+	AuditActionTeamMemberSet     = "team.member.set"
+	AuditActionTeamMemberRemoved = "team.member.removed"
+)
+
+// Only active org members can join teams.
+const memberStatusActive = "active"
+
+type teamApi struct {
+	db       *gorm.DB
+	cfg      config.TeamsConfig
+	logger   *slog.Logger
+	validate *helper.Validator
+}
+
+type TeamAPI interface {
+	CreateTeam(ctx context.Context, req *CreateTeamRequest) (*TeamResponse, error)
+	ListTeams(ctx context.Context, req *OrgRequest) (*ListTeamsResponse, error)
+	GetTeam(ctx context.Context, req *TeamRequest) (*TeamDetailResponse, error)
+	UpdateTeam(ctx context.Context, req *UpdateTeamRequest) (*TeamResponse, error)
+	DeleteTeam(ctx context.Context, req *TeamRequest) (*StatusResponse, error)
+	SetTeamMember(ctx context.Context, req *SetTeamMemberRequest) (*TeamMemberResponse, error)
+	RemoveTeamMember(ctx context.Context, req *TeamMemberRequest) (*StatusResponse, error)
+}
+
+func NewTeamService(db *gorm.DB, cfg config.TeamsConfig, logger *slog.Logger) TeamAPI {
+	return &teamApi{db: db, cfg: cfg, logger: logger, validate: helper.NewValidator(db)}
+}
+
+// @Summary      	CreateTeam
+// @Description		Creates a team in the org, or inside another team when parentId is set. Top-level teams are created by owners and admins; sub-teams also by leads of the parent team. Teams nest at most teams.maxDepth deep.
+// @Tags			Teams
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int						true	"OrgID"
+// @Param			CreateTeamRequest				body		CreateTeamRequest		true	"CreateTeamRequest"
+// @Success			201								{object}	TeamResponse
+// @Router			/api/o/{orgId}/teams			[POST]
+func (s *teamApi) CreateTeam(ctx context.Context, req *CreateTeamRequest) (*TeamResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "teams can only be managed by users")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	team := Team{
+		OrgID:       req.OrgID,
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrgTeams(tx, req.OrgID); err != nil {
+			return err
+		}
+		if err := checkCanManageUnder(ctx, tx, req.OrgID, req.CurrentUserID, req.CurrentRoleID, req.ParentID); err != nil {
+			return err
+		}
+		if req.ParentID != nil {
+			if err := s.checkParent(tx, req.OrgID, *req.ParentID, 1); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&team).Error; err != nil {
+			return nameTakenIfDuplicate(err)
+		}
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionTeamCreated,
+			TargetType:  "team",
+			TargetID:    strconv.Itoa(team.ID),
+			Details:     map[string]interface{}{"name": team.Name, "parentId": team.ParentID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("team created", "org_id", req.OrgID, "team_id", team.ID, "created_by", req.CurrentUserID)
+
+	return toResponse(team, 0), nil
+}
+
+// @Summary      	ListTeams
+// @Description		Lists all teams of the org. Nesting is given by parentId; top-level teams have none.
+// @Tags			Teams
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"OrgID"
+// @Success			200								{object}	ListTeamsResponse
+// @Router			/api/o/{orgId}/teams			[GET]
+func (s *teamApi) ListTeams(ctx context.Context, req *OrgRequest) (*ListTeamsResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var rows []teamWithCount
+	err := teamsWithCounts(db).
+		Where("teams.org_id = ?", req.OrgID).
+		Order("lower(teams.name), teams.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	res := &ListTeamsResponse{Teams: make([]TeamResponse, 0, len(rows))}
+	for _, row := range rows {
+		res.Teams = append(res.Teams, *toResponse(row.Team, row.MemberCount))
+	}
+	return res, nil
+}
+
+// @Summary      	GetTeam
+// @Description		Returns a team with its direct members and their team roles.
+// @Tags			Teams
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"OrgID"
+// @Param			teamId							path		int				true	"Team ID"
+// @Success			200								{object}	TeamDetailResponse
+// @Router			/api/o/{orgId}/teams/{teamId}	[GET]
+func (s *teamApi) GetTeam(ctx context.Context, req *TeamRequest) (*TeamDetailResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var row teamWithCount
+	result := teamsWithCounts(db).
+		Where("teams.id = ? AND teams.org_id = ?", req.TeamID, req.OrgID).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get team: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, helper.NotFound("team_not_found", "team not found")
+	}
+
+	var members []TeamMemberResponse
+	err := membersQuery(db).
+		Where("team_members.team_id = ?", req.TeamID).
+		Order("team_members.created_at, team_members.user_id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	if members == nil {
+		members = []TeamMemberResponse{}
+	}
+
+	return &TeamDetailResponse{TeamResponse: *toResponse(row.Team, row.MemberCount), Members: members}, nil
+}
+
+// @Summary      	UpdateTeam
+// @Description		Renames, describes or moves a team. Requires the lead role in the team or a team above it; moving the team also requires managing both its current and its new parent.
+// @Tags			Teams
+// @Accept			json
+// @Produce			json
+// @Param			Authorization					header		string				true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int					true	"OrgID"
+// @Param			teamId							path		int					true	"Team ID"
+// @Param			UpdateTeamRequest				body		UpdateTeamRequest	true	"UpdateTeamRequest"
+// @Success			200								{object}	TeamResponse
+// @Router			/api/o/{orgId}/teams/{teamId}	[PATCH]
+func (s *teamApi) UpdateTeam(ctx context.Context, req *UpdateTeamRequest) (*TeamResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "teams can only be managed by users")
+	}
+	if req.Name != nil {
+		*req.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		*req.Description = strings.TrimSpace(*req.Description)
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var team Team
+	var memberCount int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrgTeams(tx, req.OrgID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND org_id = ?", req.TeamID, req.OrgID).First(&team).Error; err != nil {
+			return helper.NotFoundIfMissing(err, "team_not_found", "team not found")
+		}
+
+		changes := map[string]interface{}{}
+		if req.Name != nil && *req.Name != team.Name {
+			changes["name"] = audit.Change{Old: team.Name, New: *req.Name}
+			team.Name = *req.Name
+		}
+		if req.Description != nil && *req.Description != team.Description {
+			changes["description"] = audit.Change{Old: team.Description, New: *req.Description}
+			team.Description = *req.Description
+		}
+		if req.ParentID != nil {
+			// 0 moves the team to the top level.
+			var parentID *int
+			if *req.ParentID != 0 {
+				parentID = req.ParentID
+			}
+			if (team.ParentID == nil) != (parentID == nil) || (parentID != nil && *team.ParentID != *parentID) {
+				if err := s.checkMove(ctx, tx, req, team, parentID); err != nil {
+					return err
+				}
+				changes["parentId"] = audit.Change{Old: team.ParentID, New: parentID}
+				team.ParentID = parentID
+			}
+		}
+
+		if err := tx.Model(&TeamMember{}).Where("team_id = ?", team.ID).Count(&memberCount).Error; err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		team.UpdatedAt = &now
+		if err := tx.Save(&team).Error; err != nil {
+			return nameTakenIfDuplicate(err)
+		}
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionTeamUpdated,
+			TargetType:  "team",
+			TargetID:    strconv.Itoa(team.ID),
+			Details:     changes,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponse(team, int(memberCount)), nil
+}
+
+// @Summary      	DeleteTeam
+// @Description		Deletes a team and its memberships. Teams with sub-teams cannot be deleted. Top-level teams are deleted by owners and admins; sub-teams also by leads of the parent team.
+// @Tags			Teams
+// @Produce			json
+// @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId							path		int				true	"OrgID"
+// @Param			teamId							path		int				true	"Team ID"
+// @Success			200								{object}	StatusResponse
+// @Router			/api/o/{orgId}/teams/{teamId}	[DELETE]
+func (s *teamApi) DeleteTeam(ctx context.Context, req *TeamRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "teams can only be managed by users")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrgTeams(tx, req.OrgID); err != nil {
+			return err
+		}
+		var team Team
+		if err := tx.Where("id = ? AND org_id = ?", req.TeamID, req.OrgID).First(&team).Error; err != nil {
+			return helper.NotFoundIfMissing(err, "team_not_found", "team not found")
+		}
+		if err := checkCanManageUnder(ctx, tx, req.OrgID, req.CurrentUserID, req.CurrentRoleID, team.ParentID); err != nil {
+			return err
+		}
+
+		var subteams int64
+		if err := tx.Model(&Team{}).Where("parent_id = ?", team.ID).Count(&subteams).Error; err != nil {
+			return err
+		}
+		if subteams > 0 {
+			return helper.Conflict("team_has_subteams", "delete or move the team's sub-teams first")
+		}
+
+		if err := tx.Delete(&team).Error; err != nil {
+			return err
+		}
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionTeamDeleted,
+			TargetType:  "team",
+			TargetID:    strconv.Itoa(team.ID),
+			Details:     map[string]interface{}{"name": team.Name, "parentId": team.ParentID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("team deleted", "org_id", req.OrgID, "team_id", req.TeamID, "deleted_by", req.CurrentUserID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	SetTeamMember
+// @Description		Adds an active org member to the team, or changes their team role. Requires the lead role in the team or a team above it.
+// @Tags			Teams
+// @Accept			json
+// @Produce			json
+// @Param			Authorization							header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId									path		int						true	"OrgID"
+// @Param			teamId									path		int						true	"Team ID"
+// @Param			userId									path		int						true	"User ID"
+// @Param			SetTeamMemberRequest					body		SetTeamMemberRequest	true	"SetTeamMemberRequest"
+// @Success			200										{object}	TeamMemberResponse
+// @Router			/api/o/{orgId}/teams/{teamId}/members/{userId}	[PUT]
+func (s *teamApi) SetTeamMember(ctx context.Context, req *SetTeamMemberRequest) (*TeamMemberResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "teams can only be managed by users")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var member TeamMemberResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var team Team
+		if err := tx.Where("id = ? AND org_id = ?", req.TeamID, req.OrgID).First(&team).Error; err != nil {
+			return helper.NotFoundIfMissing(err, "team_not_found", "team not found")
+		}
+
+		var active int64
+		err := tx.Table("user_org_roles").
+			Where("user_id = ? AND org_id = ? AND status = ?", req.UserID, req.OrgID, memberStatusActive).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active == 0 {
+			return helper.NotFound("member_not_found", "user is not an active member of this org")
+		}
+
+		var previous TeamMember
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("team_id = ? AND user_id = ?", req.TeamID, req.UserID).
+			First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		var oldRole interface{}
+		if err == nil {
+			if previous.Role == req.Role {
+				return scanMember(tx, req.TeamID, req.UserID, &member)
+			}
+			oldRole = previous.Role
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role"}),
+		}).Create(&TeamMember{TeamID: req.TeamID, UserID: req.UserID, Role: req.Role}).Error
+		if err != nil {
+			return err
+		}
+
+		orgID := req.OrgID
+		err = audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionTeamMemberSet,
+			TargetType:  "team",
+			TargetID:    strconv.Itoa(req.TeamID),
+			Details: map[string]interface{}{
+				"userId": req.UserID,
+				"role":   audit.Change{Old: oldRole, New: req.Role},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return scanMember(tx, req.TeamID, req.UserID, &member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// @Summary      	RemoveTeamMember
+// @Description		Removes a user from the team. Requires the lead role in the team or a team above it.
+// @Tags			Teams
+// @Produce			json
+// @Param			Authorization							header		string			true	"Authorization Key(e.g Bearer key)"
+// @Param			orgId									path		int				true	"OrgID"
+// @Param			teamId									path		int				true	"Team ID"
+// @Param			userId									path		int				true	"User ID"
+// @Success			200										{object}	StatusResponse
+// @Router			/api/o/{orgId}/teams/{teamId}/members/{userId}	[DELETE]
+func (s *teamApi) RemoveTeamMember(ctx context.Context, req *TeamMemberRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "teams can only be managed by users")
+	}
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var member TeamMember
+		result := tx.Clauses(clause.Returning{}).
+			Where("team_id = ? AND user_id = ?", req.TeamID, req.UserID).
+			Where("team_id IN (?)", tx.Model(&Team{}).Select("id").Where("org_id = ?", req.OrgID)).
+			Delete(&member)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.NotFound("team_member_not_found", "user is not a member of this team")
+		}
+
+		orgID := req.OrgID
+		return audit.Record(tx, &audit.Event{
+			OrgID:       &orgID,
+			ActorUserID: &req.CurrentUserID,
+			Action:      AuditActionTeamMemberRemoved,
+			TargetType:  "team",
+			TargetID:    strconv.Itoa(req.TeamID),
+			Details:     map[string]interface{}{"userId": req.UserID, "role": member.Role},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// SubtreeIDs returns the id of team teamID of orgID followed by the ids of
+// all teams nested inside it. It returns a not found error if the org has no
+// such team.
+func SubtreeIDs(ctx context.Context, db *gorm.DB, orgID, teamID int) ([]int, error) {
+	levels, err := subtree(db.WithContext(ctx), orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, helper.NotFound("team_not_found", "team not found")
+	}
+	ids := make([]int, 0, len(levels))
+	for _, l := range levels {
+		ids = append(ids, l.ID)
+	}
+	return ids, nil
+}
+
+// Private helper funcs
+
+type teamWithCount struct {
+	Team        `gorm:"embedded"`
+	MemberCount int
+}
+
+type teamLevel struct {
+	ID    int
+	Level int
+}
+
+func teamsWithCounts(db *gorm.DB) *gorm.DB {
+	return db.Table(TeamTableName).
+		Select("teams.*, (SELECT COUNT(*) FROM team_members WHERE team_members.team_id = teams.id) AS member_count")
+}
+
+// subtree returns the team and the teams nested inside it, each with its
+// level below the team, which is level 1, ordered by level.
+func subtree(db *gorm.DB, orgID, teamID int) ([]teamLevel, error) {
+	var levels []teamLevel
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, 1 AS level FROM teams WHERE id = ? AND org_id = ?
+			UNION ALL
+			SELECT teams.id, tree.level + 1 FROM teams JOIN tree ON teams.parent_id = tree.id
+		)
+		SELECT id, level FROM tree ORDER BY level, id`, teamID, orgID).
+		Scan(&levels).Error
+	return levels, err
+}
+
+// depth returns how deep team teamID is nested; top-level teams have depth 1.
+func depth(db *gorm.DB, teamID int) (int, error) {
+	var n int
+	err := db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM teams WHERE id = ?
+			UNION ALL
+			SELECT teams.id, teams.parent_id FROM teams JOIN chain ON teams.id = chain.parent_id
+		)
+		SELECT COUNT(*) FROM chain`, teamID).
+		Scan(&n).Error
+	return n, err
+}
+
+// checkParent checks that parentID is a team of orgID that can take a
+// subtree height levels tall without exceeding the maximum depth.
+func (s *teamApi) checkParent(tx *gorm.DB, orgID, parentID, height int) error {
+	var count int64
+	if err := tx.Model(&Team{}).Where("id = ? AND org_id = ?", parentID, orgID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return helper.FieldInvalid("parentId", "does not refer to a team in this org")
+	}
+	d, err := depth(tx, parentID)
+	if err != nil {
+		return err
+	}
+	if d+height > s.cfg.MaxDepth {
+		return helper.FieldInvalid("parentId", fmt.Sprintf("would nest teams more than %d levels deep", s.cfg.MaxDepth))
+	}
+	return nil
+}
+
+// checkMove checks that the caller may move team under parentID (nil for the
+// top level) and that the move keeps the tree valid.
+func (s *teamApi) checkMove(ctx context.Context, tx *gorm.DB, req *UpdateTeamRequest, team Team, parentID *int) error {
+	if err := checkCanManageUnder(ctx, tx, req.OrgID, req.CurrentUserID, req.CurrentRoleID, team.ParentID); err != nil {
+		return err
+	}
+	if err := checkCanManageUnder(ctx, tx, req.OrgID, req.CurrentUserID, req.CurrentRoleID, parentID); err != nil {
+		return err
+	}
+	if parentID == nil {
+		return nil
+	}
+
+	levels, err := subtree(tx, req.OrgID, team.ID)
+	if err != nil {
+		return err
+	}
+	height := 0
+	for _, l := range levels {
+		if l.ID == *parentID {
+			return helper.FieldInvalid("parentId", "cannot be the team itself or one of its sub-teams")
+		}
+		height = max(height, l.Level)
+	}
+	return s.checkParent(tx, req.OrgID, *parentID, height)
+}
+
+// Owners and admins manage all teams. Leads manage the teams below the ones
+// they lead; only owners and admins manage top-level teams.
+func checkCanManageUnder(ctx context.Context, db *gorm.DB, orgID, currentUserID, currentRoleID int, parentID *int) error {
+	if currentRoleID == 1 || currentRoleID == 2 {
+		return nil
+	}
+	if parentID == nil {
+		return helper.Forbidden("permission_denied", "only owners and admins can manage top-level teams")
+	}
+	ok, err := middleware.HasTeamRole(ctx, db, orgID, currentUserID, *parentID, middleware.TeamRoleLead)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return helper.Forbidden("permission_denied", "requires the lead role in the parent team")
+	}
+	return nil
+}
+
+// lockOrgTeams serializes changes to the org's team tree, so concurrent moves
+// cannot create cycles or exceed the maximum depth.
+func lockOrgTeams(tx *gorm.DB, orgID int) error {
+	return tx.Exec("SELECT id FROM orgs WHERE id = ? FOR NO KEY UPDATE", orgID).Error
+}
+
+func nameTakenIfDuplicate(err error) error {
+	if _, ok := helper.UniqueViolation(err); ok {
+		return helper.Conflict("team_name_taken", "a team with this name already exists at this level")
+	}
+	return err
+}
+
+func membersQuery(db *gorm.DB) *gorm.DB {
+	return db.Table(TeamMemberTableName).
+		Select("team_members.user_id, users.email, users.first_name, users.last_name, team_members.role, team_members.created_at").
+		Joins("JOIN users ON users.id = team_members.user_id")
+}
+
+func scanMember(tx *gorm.DB, teamID, userID int, member *TeamMemberResponse) error {
+	return membersQuery(tx).
+		Where("team_members.team_id = ? AND team_members.user_id = ?", teamID, userID).
+		Scan(member).Error
+}
+
+func toResponse(team Team, memberCount int) *TeamResponse {
+	return &TeamResponse{
+		ID:          team.ID,
+		ParentID:    team.ParentID,
+		Name:        team.Name,
+		Description: team.Description,
+		MemberCount: memberCount,
+		CreatedAt:   team.CreatedAt,
+		UpdatedAt:   team.UpdatedAt,
+	}
+}
@@ -0,0 +1,176 @@
+package teams
+
+import (
+	"log/slog"
+	"org-service/helper"
+	"org-service/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TeamHTTPTransport interface {
+	CreateTeam(c *fiber.Ctx) error
+	ListTeams(c *fiber.Ctx) error
+	GetTeam(c *fiber.Ctx) error
+	UpdateTeam(c *fiber.Ctx) error
+	DeleteTeam(c *fiber.Ctx) error
+	SetTeamMember(c *fiber.Ctx) error
+	RemoveTeamMember(c *fiber.Ctx) error
+}
+
+type teamHTTPTransport struct {
+	teamApi TeamAPI
+	logger  *slog.Logger
+}
+
+func NewTeamHTTPTransport(teamApi TeamAPI, logger *slog.Logger) TeamHTTPTransport {
+	return &teamHTTPTransport{teamApi: teamApi, logger: logger}
+}
+
+func (s *teamHTTPTransport) CreateTeam(c *fiber.Ctx) error {
+	req := &CreateTeamRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.CreateTeam(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (s *teamHTTPTransport) ListTeams(c *fiber.Ctx) error {
+	req := &OrgRequest{}
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.ListTeams(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *teamHTTPTransport) GetTeam(c *fiber.Ctx) error {
+	teamId, err := strconv.Atoi(c.Params("teamId"))
+	if err != nil {
+		return helper.FieldInvalid("teamId", "must be a number")
+	}
+
+	req := &TeamRequest{}
+	req.TeamID = teamId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.GetTeam(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *teamHTTPTransport) UpdateTeam(c *fiber.Ctx) error {
+	teamId, err := strconv.Atoi(c.Params("teamId"))
+	if err != nil {
+		return helper.FieldInvalid("teamId", "must be a number")
+	}
+
+	req := &UpdateTeamRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.TeamID = teamId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.UpdateTeam(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *teamHTTPTransport) DeleteTeam(c *fiber.Ctx) error {
+	teamId, err := strconv.Atoi(c.Params("teamId"))
+	if err != nil {
+		return helper.FieldInvalid("teamId", "must be a number")
+	}
+
+	req := &TeamRequest{}
+	req.TeamID = teamId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.DeleteTeam(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *teamHTTPTransport) SetTeamMember(c *fiber.Ctx) error {
+	teamId, err := strconv.Atoi(c.Params("teamId"))
+	if err != nil {
+		return helper.FieldInvalid("teamId", "must be a number")
+	}
+	userId, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return helper.FieldInvalid("userId", "must be a number")
+	}
+
+	req := &SetTeamMemberRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.TeamID = teamId
+	req.UserID = userId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.SetTeamMember(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *teamHTTPTransport) RemoveTeamMember(c *fiber.Ctx) error {
+	teamId, err := strconv.Atoi(c.Params("teamId"))
+	if err != nil {
+		return helper.FieldInvalid("teamId", "must be a number")
+	}
+	userId, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return helper.FieldInvalid("userId", "must be a number")
+	}
+
+	req := &TeamMemberRequest{}
+	req.TeamID = teamId
+	req.UserID = userId
+	req.OrgID = middleware.CtxOrgID(c)
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+
+	resp, err := s.teamApi.RemoveTeamMember(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,147 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const TableName = "action_tokens"
+
+// Purposes a token can be issued for. A token only ever works for its own
+// purpose.
+const (
+	PurposeInvitation        = "invitation"
+	PurposeEmailVerification = "email_verification"
+	PurposeEmailChange       = "email_change"
+)
+
+// Tokens are 16 characters of Crockford base32 (80 random bits), shown as
+// XXXX-XXXX-XXXX-XXXX. The alphabet has no I, L, O or U so tokens read back
+// from an email or typed by hand are unambiguous.
+const (
+	alphabet   = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	tokenLen   = 16
+	groupEvery = 4
+)
+
+// ErrInvalid is returned by Consume and Find for unknown, expired, already
+// used or wrong-purpose tokens. Callers should not tell these apart to the
+// client.
+var ErrInvalid = errors.New("token is invalid or expired")
+
+// Token is a single-use token record. The fields other than the hash say
+// what the token is for and what using it grants.
+type Token struct {
+	ID         int    `gorm:"primaryKey"`
+	TokenHash  string `gorm:"unique;not null"`
+	Purpose    string `gorm:"not null"`
+	Email      string `gorm:"not null"`
+	UserID     *int
+	OrgID      *int
+	RoleID     *int
+	Status     string
+	CreatedBy  *int
+	ExpiresAt  time.Time `gorm:"not null"`
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (Token) TableName() string {
+	return TableName
+}
+
+// Issue stores t with a new random token and returns the token to send to
+// the user. t.Purpose, t.Email and t.ExpiresAt must be set.
+func Issue(tx *gorm.DB, t *Token) (string, error) {
+	raw, err := generate()
+	if err != nil {
+		return "", err
+	}
+	t.TokenHash = Hash(raw)
+	if err := tx.Create(t).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// Consume marks the token as used and returns its record, in one statement
+// so that two concurrent requests cannot both use it. Run it in the same
+// transaction as the action the token authorizes, so a failed action leaves
+// the token unused.
+func Consume(tx *gorm.DB, purpose, raw string) (*Token, error) {
+	var t Token
+	result := tx.Model(&t).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > NOW()", Hash(raw), purpose).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalid
+	}
+	return &t, nil
+}
+
+// Find returns the record of a usable token without consuming it.
+func Find(tx *gorm.DB, purpose, raw string) (*Token, error) {
+	var t Token
+	result := tx.Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > NOW()", Hash(raw), purpose).
+		Limit(1).Find(&t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalid
+	}
+	return &t, nil
+}
+
+// Hash returns the stored form of raw. Tokens are normalized first, so
+// lower case, missing dashes and look-alike letters still match.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(Normalize(raw)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Normalize upper-cases raw, drops separators and maps the letters Crockford
+// base32 leaves out to the digits they are mistaken for.
+func Normalize(raw string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(raw) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func generate() (string, error) {
+	buf := make([]byte, tokenLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, v := range buf {
+		if i > 0 && i%groupEvery == 0 {
+			b.WriteByte('-')
+		}
+		// 256 is a multiple of 32, so this is unbiased.
+		b.WriteByte(alphabet[int(v)%len(alphabet)])
+	}
+	return b.String(), nil
+}
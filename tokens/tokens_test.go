@@ -0,0 +1,78 @@
+package tokens
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"as issued", "AB12-CD34-EF56-GH78", "AB12CD34EF56GH78"},
+		{"lower case", "ab12-cd34-ef56-gh78", "AB12CD34EF56GH78"},
+		{"no dashes", "AB12CD34EF56GH78", "AB12CD34EF56GH78"},
+		{"spaces", " AB12 CD34 EF56 GH78 ", "AB12CD34EF56GH78"},
+		{"O read as zero", "O0o0", "0000"},
+		{"I and L read as one", "IiLl1", "11111"},
+		{"empty", "", ""},
+		{"only separators", "- -", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	const issued = "10AB-CD34-EF56-GH78"
+	tests := []struct {
+		name  string
+		in    string
+		match bool
+	}{
+		{"same token", issued, true},
+		{"typed in lower case", "10ab-cd34-ef56-gh78", true},
+		{"without dashes", "10ABCD34EF56GH78", true},
+		{"look-alike letters", "lOAB-CD34-EF56-GH78", true},
+		{"different token", "10AB-CD34-EF56-GH79", false},
+		{"prefix", "10AB-CD34-EF56", false},
+	}
+	want := Hash(issued)
+	if len(want) != 64 {
+		t.Fatalf("Hash(%q) = %q, want 64 hex characters", issued, want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Hash(tt.in) == want; got != tt.match {
+				t.Errorf("Hash(%q) matches = %v, want %v", tt.in, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{4}(-[0-9A-HJKMNP-TV-Z]{4}){3}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		raw, err := generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(raw) {
+			t.Fatalf("generate() = %q, want XXXX-XXXX-XXXX-XXXX in Crockford base32", raw)
+		}
+		if Normalize(raw) != raw[0:4]+raw[5:9]+raw[10:14]+raw[15:19] {
+			t.Fatalf("Normalize changed the issued token %q", raw)
+		}
+		if seen[raw] {
+			t.Fatalf("generate() repeated %q", raw)
+		}
+		seen[raw] = true
+	}
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// RegisterDB adds gorm callbacks that wrap every statement in a client span,
+// a child of whatever span is in the statement's context (see
+// gorm.DB.WithContext). Only the SQL with placeholders is recorded, never the
+// bound values.
+func RegisterDB(db *gorm.DB) error {
+	tracer := otel.Tracer(instrumentationName)
+
+	const spanKey = "tracing:span"
+	before := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			ctx, span := tracer.Start(tx.Statement.Context, "db."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.DBSystemPostgreSQL),
+			)
+			tx.Statement.Context = ctx
+			tx.InstanceSet(spanKey, span)
+		}
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, ok := tx.InstanceGet(spanKey)
+			if !ok {
+				return
+			}
+			span, ok := v.(trace.Span)
+			if !ok {
+				return
+			}
+			defer span.End()
+
+			if table := tx.Statement.Table; table != "" {
+				span.SetName("db." + operation + " " + table)
+				span.SetAttributes(semconv.DBCollectionName(table))
+			}
+			span.SetAttributes(
+				semconv.DBOperationName(operation),
+				semconv.DBQueryText(tx.Statement.SQL.String()),
+			)
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				setError(span, tx.Error)
+			}
+		}
+	}
+
+	cb := db.Callback()
+	registrations := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range registrations {
+		if err := r.before("tracing:before_"+r.operation, before(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("tracing:after_"+r.operation, after(r.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
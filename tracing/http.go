@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"org-service/logging"
+)
+
+// HTTPMiddleware starts a server span per request, continuing the trace from
+// an incoming traceparent header. The span is named after the route pattern
+// rather than the raw path, which can carry emails and tokens. trace_id is
+// added to the request logger so log lines can be joined with traces. Errors
+// are passed on for RequestLogger to render; statusOf tells the status they
+// will get.
+func HTTPMiddleware(statusOf func(error) int) fiber.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		if sc := span.SpanContext(); sc.IsValid() {
+			logging.AddCtxAttrs(c, "trace_id", sc.TraceID().String())
+		}
+
+		err := c.Next()
+
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = statusOf(err)
+		}
+
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(status),
+		)
+		// Client errors are not failures of the server span.
+		if status >= fiber.StatusInternalServerError {
+			if err != nil {
+				setError(span, err)
+			} else {
+				span.SetStatus(codes.Error, "")
+			}
+		}
+		return err
+	}
+}
+
+// headerCarrier adapts Fiber's request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
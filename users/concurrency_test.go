@@ -0,0 +1,232 @@
+package users
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"org-service/config"
+	"org-service/db/dbtest"
+	"org-service/helper"
+	orgsvc "org-service/org"
+	"org-service/tokens"
+
+	"gopkg.in/gomail.v2"
+	"gorm.io/gorm"
+)
+
+const parallelRequests = 8
+
+func TestInviteUserConcurrentSameEmail(t *testing.T) {
+	conn, svc, orgID, ownerID := setupInviteTest(t)
+	email := dbtest.Unique("invitee") + "@example.com"
+
+	errs := make([]error, parallelRequests)
+	var wg sync.WaitGroup
+	for i := range parallelRequests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.InviteUser(context.Background(), &InviteUserRequest{
+				Email:         email,
+				RoleID:        3,
+				OrgID:         orgID,
+				CurrentUserID: ownerID,
+				CurrentRoleID: 1,
+			})
+		}()
+	}
+	wg.Wait()
+
+	invited := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			invited++
+		case helper.HasCode(err, "already_invited"):
+		default:
+			t.Errorf("InviteUser: unexpected error %v", err)
+		}
+	}
+	if invited != 1 {
+		t.Errorf("InviteUser succeeded %d times, want 1", invited)
+	}
+
+	var users []User
+	if err := conn.Where("email = ?", email).Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("got %d users with email %s, want 1", len(users), email)
+	}
+
+	var memberships int64
+	if err := conn.Table(orgsvc.UserOrgRoleTableName).
+		Where("user_id = ? AND org_id = ?", users[0].ID, orgID).
+		Count(&memberships).Error; err != nil {
+		t.Fatal(err)
+	}
+	if memberships != 1 {
+		t.Errorf("got %d user_org_roles rows for the invitee, want 1", memberships)
+	}
+}
+
+func TestAcceptInvitationConcurrentSameToken(t *testing.T) {
+	conn, svc, orgID, ownerID := setupInviteTest(t)
+	email := dbtest.Unique("invitee") + "@example.com"
+
+	_, err := svc.InviteUser(context.Background(), &InviteUserRequest{
+		Email:         email,
+		RoleID:        3,
+		OrgID:         orgID,
+		CurrentUserID: ownerID,
+		CurrentRoleID: 1,
+	})
+	if err != nil {
+		t.Fatalf("InviteUser: %v", err)
+	}
+	// The token only travels by mail, so swap the stored hash for one of a
+	// token the test knows.
+	token := dbtest.Unique("token")
+	result := conn.Table(tokens.TableName).
+		Where("purpose = ? AND email = ? AND org_id = ? AND consumed_at IS NULL", tokens.PurposeInvitation, email, orgID).
+		Update("token_hash", tokens.Hash(token))
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("replacing invitation token: %v (%d rows)", result.Error, result.RowsAffected)
+	}
+
+	errs := make([]error, parallelRequests)
+	var wg sync.WaitGroup
+	for i := range parallelRequests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.AcceptInvitation(context.Background(), &AcceptInvitationRequest{
+				Token:           token,
+				UserName:        dbtest.Unique("u"),
+				FirstName:       "Ada",
+				LastName:        "Lovelace",
+				Password:        "Secret123",
+				ConfirmPassword: "Secret123",
+			})
+		}()
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case helper.HasCode(err, "invalid_invitation"):
+		default:
+			t.Errorf("AcceptInvitation: unexpected error %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("AcceptInvitation succeeded %d times, want 1", accepted)
+	}
+
+	var user User
+	if err := conn.Where("email = ?", email).First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	if err := conn.Table(orgsvc.UserOrgRoleTableName).
+		Where("user_id = ? AND org_id = ?", user.ID, orgID).
+		Pluck("status", &statuses).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0] != UserStatusActive {
+		t.Errorf("got memberships %v for the invitee, want one active", statuses)
+	}
+}
+
+// setupInviteTest returns a user service mailing to a local SMTP sink and an
+// org owned by a new user.
+func setupInviteTest(t *testing.T) (*gorm.DB, UserAPI, int, int) {
+	t.Helper()
+
+	conn := dbtest.Open(t)
+	host, port := startSMTPSink(t)
+	cfg := &config.Config{
+		UIAppURL: "http://localhost:3000",
+		Mail:     config.MailConfig{Host: host, Port: port, From: "noreply@example.com"},
+		Tokens:   config.TokenConfig{InvitationTTL: time.Hour, EmailVerificationTTL: time.Hour},
+	}
+	svc := NewUserService(conn, gomail.NewDialer(host, port, "", ""), nil, cfg, dbtest.Logger())
+
+	ownerID := dbtest.CreateUser(t, conn, dbtest.Unique("owner")+"@example.com")
+	org, err := orgsvc.NewOrgService(conn, nil, 0, dbtest.Logger()).AddOrg(context.Background(), &orgsvc.AddOrgRequest{
+		Name:   dbtest.Unique("Org "),
+		Size:   "1-10",
+		UserID: ownerID,
+	})
+	if err != nil {
+		t.Fatalf("AddOrg: %v", err)
+	}
+	return conn, svc, org.ID, ownerID
+}
+
+// startSMTPSink accepts and discards every message sent to it.
+func startSMTPSink(t *testing.T) (string, int) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(c)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func serveSMTP(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	reply := func(line string) { fmt.Fprintf(c, "%s\r\n", line) }
+
+	reply("220 localhost")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+			}
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package users
 
+import "time"
+
 const (
-	UserTableName = "users"
+	UserTableName    = "users"
+	SessionTableName = "user_sessions"
 )
 
 type User struct {
@@ -20,8 +23,14 @@ type User struct {
 	Role          string
 }
 
+// registered reports whether the user has signed up. Users created by an
+// invitation have no username until they accept it.
+func (u *User) registered() bool {
+	return u.Username != nil
+}
+
 type IDRequest struct {
-	OrgID int `json:"orgId"`
+	OrgID int `json:"orgId" validate:"required"`
 }
 
 type GetUsersResponse struct {
@@ -30,8 +39,8 @@ type GetUsersResponse struct {
 
 type GetUserRequest struct {
 	ID    int    `json:"id"`
-	OrgID int    `json:"orgId"`
-	Email string `json:"email"`
+	OrgID int    `json:"orgId" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
 }
 
 type GetUserResponse struct {
@@ -39,42 +48,167 @@ type GetUserResponse struct {
 }
 
 type ChangeUserRoleRequest struct {
-	OrgID     int `json:"-"`
-	UserID    int `json:"userId"`
-	NewRoleID int `json:"newRoleId"`
+	OrgID         int `json:"-" validate:"required"`
+	UserID        int `json:"userId" validate:"required"`
+	NewRoleID     int `json:"newRoleId" validate:"required,oneof=1 2"`
+	CurrentUserID int `json:"-"`
+	CurrentRoleID int `json:"-"`
 }
 
 type StatusResponse struct {
 	Status bool `json:"status"`
 }
 
+// ChangeUserStatusRequest can also sign the member out of the org when
+// deactivating or rejecting them.
 type ChangeUserStatusRequest struct {
-	OrgID  int    `json:"-"`
-	UserID int    `json:"userId"`
-	Status string `json:"status"`
+	OrgID          int    `json:"-" validate:"required"`
+	UserID         int    `json:"userId" validate:"required"`
+	Status         string `json:"status" validate:"required,oneof=active inactive rejected"`
+	RevokeSessions bool   `json:"revokeSessions"`
+	CurrentUserID  int    `json:"-"`
+	CurrentRoleID  int    `json:"-"`
+	APIKeyID       int    `json:"-"`
 }
 
 type InviteUserRequest struct {
-	Email         string `json:"email"`
-	RoleID        int    `json:"roleId"`
-	OrgID         int    `json:"orgId"`
+	Email         string `json:"email" validate:"required,email,max=254"`
+	RoleID        int    `json:"roleId" validate:"required,roleid"`
+	OrgID         int    `json:"orgId" validate:"required"`
 	CurrentUserID int    `json:"-"`
 	CurrentRoleID int    `json:"-"`
 }
 
 type AcceptInvitationRequest struct {
-	Token           string `json:"token"`
-	UserName        string `json:"username"`
-	FirstName       string `json:"firstName"`
-	LastName        string `json:"lastName"`
-	Password        string `json:"password"`
-	ConfirmPassword string `json:"confirmPassword"`
+	Token           string `json:"token" validate:"required"`
+	UserName        string `json:"username" validate:"required,username"`
+	FirstName       string `json:"firstName" validate:"required,max=100"`
+	LastName        string `json:"lastName" validate:"required,max=100"`
+	Password        string `json:"password" validate:"required,password"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=Password"`
 }
 
 type AcceptInvitationResponse struct {
 	InviteAccepted bool   `json:"inviteAccepted"`
 	OrgSlug        string `json:"orgSlug"`
-	Token          string `json:"token"`
 	Status         string `json:"status"`
 	RoleID         int    `json:"roleId"`
-}
\ No newline at end of file
+}
+
+type AcceptInvitationAsUserRequest struct {
+	Token         string `json:"-" validate:"required"`
+	CurrentUserID int    `json:"-" validate:"required"`
+}
+
+type InvitationRequest struct {
+	Token string `json:"-" validate:"required"`
+}
+
+type InvitationResponse struct {
+	Email          string    `json:"email"`
+	OrgName        string    `json:"orgName"`
+	OrgSlug        string    `json:"orgSlug"`
+	InvitedBy      string    `json:"invitedBy"`
+	RoleID         int       `json:"roleId"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	SignupRequired bool      `json:"signupRequired"`
+}
+type VerifyEmailRequest struct {
+	Token string `json:"-" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	CurrentUserID int `json:"-" validate:"required"`
+}
+
+type MeRequest struct {
+	CurrentUserID int `json:"-" validate:"required"`
+}
+
+type ProfileResponse struct {
+	ID            int            `json:"id"`
+	Email         string         `json:"email"`
+	Username      string         `json:"username"`
+	FirstName     string         `json:"firstName"`
+	LastName      string         `json:"lastName"`
+	Phone         string         `json:"phone"`
+	AvatarImgKey  string         `json:"avatarImgKey"`
+	AvatarURLs    map[int]string `json:"avatarUrls,omitempty"`
+	VerifiedEmail bool           `json:"verifiedEmail"`
+}
+
+// UpdateProfileRequest changes only the fields that are present.
+type UpdateProfileRequest struct {
+	CurrentUserID int     `json:"-" validate:"required"`
+	Username      *string `json:"username" validate:"omitempty,username"`
+	FirstName     *string `json:"firstName" validate:"omitempty,max=100"`
+	LastName      *string `json:"lastName" validate:"omitempty,max=100"`
+	Phone         *string `json:"phone" validate:"omitempty,max=30"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentUserID   int    `json:"-" validate:"required"`
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,password"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=NewPassword"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentUserID int    `json:"-" validate:"required"`
+	NewEmail      string `json:"newEmail" validate:"required,email,max=254"`
+	Password      string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"-" validate:"required"`
+}
+
+type UploadAvatarRequest struct {
+	CurrentUserID int    `json:"-" validate:"required"`
+	Image         []byte `json:"-" validate:"required"`
+}
+
+// Session is a sign-in of a user, recorded by middleware.Authentication.
+type Session struct {
+	ID           int `gorm:"primaryKey"`
+	UserID       int
+	SessionKey   string
+	UserAgent    string
+	IP           string `gorm:"column:ip"`
+	CreatedAt    time.Time
+	LastActiveAt time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+	RevokedBy    *int
+}
+
+func (Session) TableName() string {
+	return SessionTableName
+}
+
+type SessionsRequest struct {
+	CurrentUserID    int `json:"-" validate:"required"`
+	CurrentSessionID int `json:"-"`
+}
+
+type RevokeSessionRequest struct {
+	SessionID        int `json:"-" validate:"required"`
+	CurrentUserID    int `json:"-" validate:"required"`
+	CurrentSessionID int `json:"-"`
+}
+
+type SessionResponse struct {
+	ID           int       `json:"id"`
+	Device       string    `json:"device"`
+	UserAgent    string    `json:"userAgent"`
+	IP           string    `json:"ip"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	// Current is the session making the request.
+	Current bool `json:"current"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
@@ -1,18 +1,105 @@
 package users
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"org-service/helper"
+	"org-service/middleware"
 
-func RegisterRoutes(router fiber.Router, orgRouter fiber.Router, userHttpTransport UserHTTPTransport, authMiddleware func(c *fiber.Ctx) error) {
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route names for the limits in config.RateLimitConfig.Routes.
+const (
+	rateLimitInvite             = "invite"
+	rateLimitAcceptInvitation   = "accept_invitation"
+	rateLimitVerifyEmail        = "verify_email"
+	rateLimitResendVerification = "resend_verification"
+	rateLimitChangePassword     = "change_password"
+	rateLimitChangeEmail        = "change_email"
+	rateLimitUploadAvatar       = "upload_avatar"
+)
+
+func RegisterRoutes(router fiber.Router, orgRouter fiber.Router, userHttpTransport UserHTTPTransport, authMiddleware func(c *fiber.Ctx) error, limiter middleware.RateLimiter, rbac middleware.RBAC) {
 	baseUserRouter := router.Group("/users")
-	baseUserRouter.Post("/invite/accept/:token", userHttpTransport.AcceptInvitation)
+	baseUserRouter.Post("/invite/accept/:token",
+		limiter.Limit(rateLimitAcceptInvitation),
+		limiter.Lockout(rateLimitAcceptInvitation, middleware.ByIP, isInvalidInvitation),
+		userHttpTransport.AcceptInvitation)
+
+	// resend is registered first so it is not taken for a token
+	baseUserRouter.Post("/verify-email/resend",
+		authMiddleware,
+		limiter.Limit(rateLimitResendVerification),
+		userHttpTransport.ResendVerificationEmail)
+	baseUserRouter.Post("/verify-email/:token",
+		limiter.Limit(rateLimitVerifyEmail),
+		limiter.Lockout(rateLimitVerifyEmail, middleware.ByIP, isInvalidVerificationToken),
+		userHttpTransport.VerifyEmail)
+	baseUserRouter.Post("/confirm-email/:token",
+		limiter.Limit(rateLimitVerifyEmail),
+		limiter.Lockout(rateLimitVerifyEmail, middleware.ByIP, isInvalidVerificationToken),
+		userHttpTransport.ConfirmEmailChange)
+
+	// The current user's own account
+	meRouter := baseUserRouter.Group("/me", authMiddleware)
+	meRouter.Get("/", userHttpTransport.GetMe)
+	meRouter.Patch("/", userHttpTransport.UpdateMe)
+	// Credentials, ownership and API keys stay with the account holder: a
+	// superadmin impersonating them gets impersonation_forbidden.
+	meRouter.Post("/password",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitChangePassword),
+		limiter.Lockout(rateLimitChangePassword, middleware.ByUser, isInvalidCurrentPassword),
+		userHttpTransport.ChangePassword)
+	// Wrong passwords on either endpoint share one lockout, so switching
+	// endpoints does not buy more guesses.
+	meRouter.Post("/email",
+		middleware.NotWhileImpersonating,
+		limiter.Limit(rateLimitChangeEmail),
+		limiter.Lockout(rateLimitChangePassword, middleware.ByUser, isInvalidCurrentPassword),
+		userHttpTransport.ChangeEmail)
+	meRouter.Put("/avatar", limiter.Limit(rateLimitUploadAvatar), userHttpTransport.UploadAvatar)
+	meRouter.Delete("/avatar", userHttpTransport.DeleteAvatar)
+	meRouter.Get("/sessions", userHttpTransport.ListSessions)
+	meRouter.Delete("/sessions/:sessionId", middleware.NotWhileImpersonating, userHttpTransport.RevokeSession)
+
+	// Invitation links for the UI: preview before deciding to sign up or sign
+	// in, and accept as the signed-in user.
+	invitationRouter := router.Group("/invitations")
+	invitationRouter.Get("/:token",
+		limiter.Limit(rateLimitAcceptInvitation),
+		limiter.Lockout(rateLimitAcceptInvitation, middleware.ByIP, isInvalidInvitation),
+		userHttpTransport.GetInvitation)
+	invitationRouter.Post("/:token/accept",
+		authMiddleware,
+		limiter.Limit(rateLimitAcceptInvitation),
+		limiter.Lockout(rateLimitAcceptInvitation, middleware.ByIP, isInvalidInvitation),
+		userHttpTransport.AcceptInvitationAsUser)
 	
 	userRouter := orgRouter.Group("/users")
-	userRouter.Put("/change-user-role", userHttpTransport.ChangeUserRole)
-	userRouter.Put("/change-user-status", userHttpTransport.ChangeUserStatus)
+	// Privileged routes: orgs can require owners and admins to have passed MFA
+	userRouter.Put("/change-user-role", middleware.NotWhileImpersonating, rbac.RequireScope(middleware.ScopeUsersManage), rbac.RequireMFA, userHttpTransport.ChangeUserRole)
+	userRouter.Put("/change-user-status", rbac.RequireScope(middleware.ScopeUsersManage), rbac.RequireMFA, userHttpTransport.ChangeUserStatus)
 
 
 	// org routes
 	orgUserRouter := orgRouter.Group("/users")
 
-	orgUserRouter.Get("/invite/:email/:roleId", userHttpTransport.InviteUser)
+	orgUserRouter.Get("/invite/:email/:roleId?", limiter.Limit(rateLimitInvite), rbac.RequireMFA, userHttpTransport.InviteUser)
+}
+
+// isInvalidInvitation counts guessed or expired tokens towards the lockout.
+func isInvalidInvitation(err error) bool {
+	return helper.HasCode(err, "invalid_invitation")
+}
+
+// isInvalidVerificationToken counts guessed or expired verification tokens
+// towards the lockout.
+func isInvalidVerificationToken(err error) bool {
+	return helper.HasCode(err, "invalid_verification_token")
+}
+
+// isInvalidCurrentPassword counts wrong current passwords towards the
+// lockout.
+func isInvalidCurrentPassword(err error) bool {
+	return helper.HasCode(err, "invalid_current_password")
 }
@@ -1,21 +1,30 @@
 package users
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
+	"log/slog"
+	"org-service/audit"
+	"org-service/avatars"
+	"org-service/config"
 	"org-service/helper"
+	"org-service/metrics"
 	orgsvc "org-service/org"
-	"os"
+	"org-service/storage"
+	"org-service/tokens"
+	"org-service/tracing"
 	"strconv"
 	"strings"
 	"time"
 
-	"errors"
-
-	"github.com/gofiber/fiber/v2/log"
-	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/gomail.v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -26,51 +35,84 @@ const (
 	UserStatusReject   = string("rejected")
 )
 
+// AuditActionSessionsRevoked is recorded when an admin signs a member out
+// of an org.
+const AuditActionSessionsRevoked = "user.sessions.revoked"
+
+// Mail templates, also used as the metrics label.
+const (
+	mailTemplateInvitation     = "invitation"
+	mailTemplateMemberApproved = "member_approved"
+	mailTemplateMemberRejected = "member_rejected"
+	mailTemplateVerifyEmail    = "verify_email"
+	mailTemplateEmailChange    = "email_change"
+)
+
 type userApi struct {
 	db *gorm.DB
 	dialer *gomail.Dialer
-	uiAppUrl string
-	logger *log.AllLogger
+	blobs storage.BlobStore
+	cfg *config.Config
+	logger *slog.Logger
+	validate *helper.Validator
 }
 
 type UserAPI interface {
-	GetUser(req *GetUserRequest) (*GetUserResponse, error)
-	GetUsers(req *IDRequest) (*GetUsersResponse, error)
-	ChangeUserRole(req *ChangeUserRoleRequest) (*StatusResponse, error)
-	ChangeUserStatus(req *ChangeUserStatusRequest) (*StatusResponse, error)
-	InviteUser(req *InviteUserRequest) (*StatusResponse, error)
-	AcceptInvitation(req *AcceptInvitationRequest) (*AcceptInvitationResponse, error)
+	GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error)
+	GetUsers(ctx context.Context, req *IDRequest) (*GetUsersResponse, error)
+	ChangeUserRole(ctx context.Context, req *ChangeUserRoleRequest) (*StatusResponse, error)
+	ChangeUserStatus(ctx context.Context, req *ChangeUserStatusRequest) (*StatusResponse, error)
+	InviteUser(ctx context.Context, req *InviteUserRequest) (*StatusResponse, error)
+	AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*AcceptInvitationResponse, error)
+	AcceptInvitationAsUser(ctx context.Context, req *AcceptInvitationAsUserRequest) (*AcceptInvitationResponse, error)
+	GetInvitation(ctx context.Context, req *InvitationRequest) (*InvitationResponse, error)
+	VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*StatusResponse, error)
+	ResendVerificationEmail(ctx context.Context, req *ResendVerificationRequest) (*StatusResponse, error)
+	GetMe(ctx context.Context, req *MeRequest) (*ProfileResponse, error)
+	UpdateMe(ctx context.Context, req *UpdateProfileRequest) (*ProfileResponse, error)
+	ChangePassword(ctx context.Context, req *ChangePasswordRequest) (*StatusResponse, error)
+	ChangeEmail(ctx context.Context, req *ChangeEmailRequest) (*StatusResponse, error)
+	ConfirmEmailChange(ctx context.Context, req *ConfirmEmailChangeRequest) (*StatusResponse, error)
+	UploadAvatar(ctx context.Context, req *UploadAvatarRequest) (*ProfileResponse, error)
+	DeleteAvatar(ctx context.Context, req *MeRequest) (*ProfileResponse, error)
+	ListSessions(ctx context.Context, req *SessionsRequest) (*SessionsResponse, error)
+	RevokeSession(ctx context.Context, req *RevokeSessionRequest) (*StatusResponse, error)
 }
 
-func NewUserService(db *gorm.DB, dialer *gomail.Dialer, uiAppUrl string) UserAPI {
+func NewUserService(db *gorm.DB, dialer *gomail.Dialer, blobs storage.BlobStore, cfg *config.Config, logger *slog.Logger) UserAPI {
 	return &userApi{
+		logger: logger,
 		db: db,
 		dialer: dialer,
-		uiAppUrl: uiAppUrl,
+		blobs: blobs,
+		cfg: cfg,
+		validate: helper.NewValidator(db),
 	}
 }
 
-func (s *userApi) GetUsers(req *IDRequest) (*GetUsersResponse, error) {
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("orgId is required")
+func (s *userApi) GetUsers(ctx context.Context, req *IDRequest) (*GetUsersResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
 	users := []*User{}
-	if err := s.db.Where("org_id = ?", req.OrgID).Find(&users).Error; err != nil {
+	if err := db.Where("org_id = ?", req.OrgID).Find(&users).Error; err != nil {
 		return nil, err
 	}
 
 	return &GetUsersResponse{Users: users}, nil
 }
 
-func (s *userApi) GetUser(req *GetUserRequest) (*GetUserResponse, error) {
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("orgId is required")
+func (s *userApi) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
 	user := &User{}
-	if err := s.db.Where("org_id = ? AND email = ?", req.OrgID, req.Email).First(&user).Error; err != nil {
-		return nil, err
+	if err := db.Where("org_id = ? AND email = ?", req.OrgID, req.Email).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
 	}
 
 	return &GetUserResponse{User: user}, nil
@@ -78,7 +120,7 @@ func (s *userApi) GetUser(req *GetUserRequest) (*GetUserResponse, error) {
 
 // NOTE: This API Endpoint FOR NOW will be used only to change the admin to owner and vice-versa!
 // @Summary      	ChangeUserRole
-// @Description	Validates org id and user id, and new role id, will query DB in users for user by user id, then tries to change the role from admin to owner or vice-versa.
+// @Description	Validates org id and user id, and new role id, will query DB in users for user by user id, then tries to change the role from admin to owner or vice-versa. Only owners can change roles, and the last active owner cannot be demoted.
 // @Tags			Users
 // @Produce			json
 // @Param			Authorization					header		string			true	"Authorization Key(e.g Bearer key)"
@@ -86,53 +128,56 @@ func (s *userApi) GetUser(req *GetUserRequest) (*GetUserResponse, error) {
 // @Param			ChangeUserRoleRequest	body		ChangeUserRoleRequest	true	"ChangeUserRoleRequest"
 // @Success		200								{object}	StatusResponse
 // @Router			/o/{orgId}/users/change-user-role	[PUT]
-func (s *userApi) ChangeUserRole(req *ChangeUserRoleRequest) (res *StatusResponse, err error) {
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("orgId is required")
-	}
-
-	if req.UserID == 0 {
-		return nil, fmt.Errorf("userId is required")
+func (s *userApi) ChangeUserRole(ctx context.Context, req *ChangeUserRoleRequest) (res *StatusResponse, err error) {
+	db := s.db.WithContext(ctx)
+	if req.CurrentUserID == 0 {
+		return nil, helper.Unauthorized("unauthorized", "roles can only be changed by users")
 	}
-
-	if req.NewRoleID == 0 {
-		return nil, fmt.Errorf("newRoleId is required")
+	if req.CurrentRoleID != 1 {
+		return nil, helper.Forbidden("permission_denied", "only owners can change roles")
 	}
-
-	if req.NewRoleID != 1 && req.NewRoleID != 2 {
-		return nil, fmt.Errorf("invalid roleId")
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
 	var user User 
-	result := s.db.Table(UserTableName).Where("id = ?", req.UserID).First(&user)
+	result := db.Table(UserTableName).Where("id = ?", req.UserID).First(&user)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "user_not_found", "user not found")
 	}
 
 	var userOrgRole orgsvc.UserOrgRole
-	result = s.db.Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).First(&userOrgRole)
+	result = db.Where("user_id = ? AND org_id = ?", req.UserID, req.OrgID).First(&userOrgRole)
 	if result.Error != nil {
-		fmt.Errorf("userOrgRole not found")
+		return nil, helper.NotFoundIfMissing(result.Error, "membership_not_found", "userOrgRole not found")
 	}
 	if userOrgRole.RoleID != 1 && userOrgRole.RoleID != 2 {
-		return nil, fmt.Errorf("user role is not valid")
+		return nil, helper.Forbidden("role_not_changeable", "user role is not valid")
 	}
 
 	if userOrgRole.RoleID == req.NewRoleID {
-		return nil, fmt.Errorf("user role is already set to the new role")
+		return nil, helper.Conflict("role_unchanged", "user role is already set to the new role")
 	}
 
-	userOrgRole.RoleID = req.NewRoleID
-	result = s.db.Model(&userOrgRole).Where("org_id = ? AND user_id = ?", userOrgRole.OrgID, userOrgRole.UserID).Updates(&userOrgRole)
-	if result.Error != nil {
-		return nil, result.Error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if userOrgRole.RoleID == 1 && userOrgRole.Status == UserStatusActive {
+			if err := ensureAnotherActiveOwner(tx, req.OrgID, req.UserID); err != nil {
+				return err
+			}
+		}
+
+		userOrgRole.RoleID = req.NewRoleID
+		return tx.Model(&userOrgRole).Where("org_id = ? AND user_id = ?", userOrgRole.OrgID, userOrgRole.UserID).Updates(&userOrgRole).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &StatusResponse{Status: true}, nil
 }
 
 // @Summary      	ChangeUserStatus
-// @Description	Validates org id and user id, and status, will try to find user by user id, then tries to change the status.
+// @Description	Validates org id and user id, and status, will try to find user by user id, then tries to change the status. Only owners, admins and API keys with the users:manage scope can change statuses, and only owners can change an owner's status. The last active owner cannot be deactivated or rejected. With revokeSessions, a deactivated or rejected member is also signed out of this org: sessions they began before the change are refused here, even once they are reactivated, but keep working in their other orgs.
 // @Tags			Users
 // @Produce			json
 // @Param			Authorization						header		string			true	"Authorization Key(e.g Bearer key)"
@@ -140,35 +185,37 @@ func (s *userApi) ChangeUserRole(req *ChangeUserRoleRequest) (res *StatusRespons
 // @Param			ChangeUserStatusRequest	body		ChangeUserStatusRequest	true	"ChangeUserStatusRequest"
 // @Success			200									{object}	StatusResponse
 // @Router			/o/{orgId}/users/change-user-status	[PUT]
-func (s *userApi) ChangeUserStatus(req *ChangeUserStatusRequest) (res *StatusResponse, err error) {
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("orgId is required")
+func (s *userApi) ChangeUserStatus(ctx context.Context, req *ChangeUserStatusRequest) (res *StatusResponse, err error) {
+	db := s.db.WithContext(ctx)
+	// API keys reach here only with the users:manage scope
+	if req.APIKeyID == 0 && req.CurrentRoleID != 1 && req.CurrentRoleID != 2 {
+		return nil, helper.Forbidden("permission_denied", "only owners and admins can change a member's status")
 	}
-
-	if req.UserID == 0 {
-		return nil, fmt.Errorf("userId is required")
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
-
-	if req.Status != UserStatusActive && req.Status != UserStatusInactive && req.Status != UserStatusReject {
-		return nil, fmt.Errorf("invalid status")
+	if req.RevokeSessions {
+		if req.Status == UserStatusActive {
+			return nil, helper.FieldInvalid("revokeSessions", "only applies when deactivating or rejecting a member")
+		}
 	}
 
 	var user User
-	result := s.db.Table(UserTableName).Where("id = ?", req.UserID).First(&user)
+	result := db.Table(UserTableName).Where("id = ?", req.UserID).First(&user)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "user_not_found", "user not found")
 	}
 
 	var org orgsvc.Org
-	result = s.db.Table(orgsvc.OrgTableName).Where("id = ?", req.OrgID).First(&org)
+	result = db.Table(orgsvc.OrgTableName).Where("id = ?", req.OrgID).First(&org)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "org_not_found", "org not found")
 	}
 
 	var userOrgRole orgsvc.UserOrgRole
-	result = s.db.Where("org_id = ? AND user_id = ?", req.OrgID, req.UserID).First(&userOrgRole)
+	result = db.Where("org_id = ? AND user_id = ?", req.OrgID, req.UserID).First(&userOrgRole)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "membership_not_found", "user is not a member of this organization")
 	}
 
 	sendApprovedUserEmail := false
@@ -181,7 +228,12 @@ func (s *userApi) ChangeUserStatus(req *ChangeUserStatusRequest) (res *StatusRes
 	}
 
 	if userOrgRole.Status == req.Status {
-		return nil, fmt.Errorf("user has already this status")
+		return nil, helper.Conflict("status_unchanged", "user has already this status")
+	}
+
+	// Owners are only managed by owners, never by API keys
+	if userOrgRole.RoleID == 1 && (req.APIKeyID != 0 || req.CurrentRoleID != 1) {
+		return nil, helper.Forbidden("permission_denied", "only owners can change an owner's status")
 	}
 
 	userActive := user.Active
@@ -190,35 +242,57 @@ func (s *userApi) ChangeUserStatus(req *ChangeUserStatusRequest) (res *StatusRes
 		userActive = false
 	}
 
-	user.Active = userActive
-	result = s.db.Table(UserTableName).Save(&user)
-	if result.Error != nil {
-		return nil, result.Error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if userOrgRole.RoleID == 1 && userOrgRole.Status == UserStatusActive {
+			if err := ensureAnotherActiveOwner(tx, req.OrgID, req.UserID); err != nil {
+				return err
+			}
+		}
+
+		user.Active = userActive
+		if err := tx.Table(UserTableName).Save(&user).Error; err != nil {
+			return err
+		}
+
+		userOrgRole.Status = req.Status
+		err := tx.Model(&userOrgRole).Where("org_id = ? AND user_id = ?", userOrgRole.OrgID, userOrgRole.UserID).Updates(&userOrgRole).Error
+		if err != nil || !req.RevokeSessions {
+			return err
+		}
+		return signOutOfOrg(tx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.RevokeSessions {
+		s.logger.Info("member signed out of org", "org_id", req.OrgID, "user_id", req.UserID)
 	}
 
-	userOrgRole.Status = req.Status
-	result = s.db.Model(&userOrgRole).Where("org_id = ? AND user_id = ?", userOrgRole.OrgID, userOrgRole.UserID).Updates(&userOrgRole)
-	if result.Error != nil {
-		return nil, result.Error
+	var settings orgsvc.OrgSettings
+	if sendApprovedUserEmail || sendRejectUserEmail {
+		settings, err = orgsvc.LoadSettings(db, org.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if sendApprovedUserEmail {
-		orgLink := s.uiAppUrl + "/o/" + org.Slug
+		orgLink := s.cfg.UIAppURL + "/o/" + org.Slug
 
 		m := gomail.NewMessage()	
-		m.SetHeader("From", "influxoks@gmail.com")
+		m.SetHeader("From", s.cfg.Mail.From)
 		m.SetHeader("To", user.Email)
 		m.SetHeader("Subject", "Your account has been approved")
-		m.SetBody("text/html", fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
+		m.SetBody("text/html", brandedEmail(settings, fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
 			Congratulations! You have now been approved by the Organization administrator to join %s!<br/><br/>
 
-			<a href='%s'>Explore Organization</a><br/><br/>
+			%s<br/><br/>
 
 			Thank you, <br/>
 			Vezhguesi Team
-		`, org.Name, orgLink))
+		`, org.Name, emailButton(settings, orgLink, "Explore Organization"))))
 
-		err = s.dialer.DialAndSend(m)
+		err = s.sendMail(ctx, mailTemplateMemberApproved, m)
 		if err != nil {
 			return nil, err
 		}
@@ -226,13 +300,14 @@ func (s *userApi) ChangeUserStatus(req *ChangeUserStatusRequest) (res *StatusRes
 
 	if sendRejectUserEmail {
 		m := gomail.NewMessage()	
-		m.SetHeader("From", "influxoks@gmail.com")
+		m.SetHeader("From", s.cfg.Mail.From)
 		m.SetHeader("To", user.Email)
 		m.SetHeader("Subject", "Your account has been rejected")
-		m.SetBody("text/html", fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
+		m.SetBody("text/html", brandedEmail(settings, fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
 			Unfortunately, your account has been rejected by the Organization administrator in %s.<br/><br/>
-		`, org.Name))
+		`, org.Name)))
 
+		err = s.sendMail(ctx, mailTemplateMemberRejected, m)
 		if err != nil {
 			return nil, err
 		}
@@ -241,42 +316,72 @@ func (s *userApi) ChangeUserStatus(req *ChangeUserStatusRequest) (res *StatusRes
 	return &StatusResponse{Status: true}, nil
 }
 
+// ensureAnotherActiveOwner fails unless the org has an active owner other
+// than userID. It locks the owners' memberships, so two owners cannot demote
+// or deactivate each other at the same time.
+func ensureAnotherActiveOwner(tx *gorm.DB, orgID, userID int) error {
+	var ownerIDs []int
+	err := tx.Model(&orgsvc.UserOrgRole{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("org_id = ? AND role_id = 1 AND status = ?", orgID, UserStatusActive).
+		Order("user_id").
+		Pluck("user_id", &ownerIDs).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range ownerIDs {
+		if id != userID {
+			return nil
+		}
+	}
+	return helper.Conflict("last_owner", "the org must keep at least one active owner")
+}
+
 // @Summary      	InviteUser
-// @Description	Validates email, role ID in request, checks in DB if req email exists with req orgId, if not generates a JWT token, send via email a UI app URL containing the token.
+// @Description	Validates email, role ID in request (the org's default role if left out), checks in DB if req email exists with req orgId, if not generates a single-use invitation token (stored hashed, with the role and status to grant), send via email a UI app URL containing the token. Only owners can invite owners and only owners and admins can invite admins. The invitation expiry, whether the member needs approval and the email branding come from the org settings.
 // @Tags			Users
 // @Accept			json
 // @Produce			json
 // @Param			Authorization			header		string	true	"Authorization Key(e.g Bearer key)"
 // @Param			orgId					path		int		true	"OrgID"
 // @Param			email					path		string	true	"Email"
-// @Param			roleId					path		int		true	"RoleID"
+// @Param			roleId					path		int		false	"RoleID"
 // @Success			200						{object}		StatusResponse
 // @Router			/api/o/{orgId}/users/invite/{email}/{roleId}	[GET]
-func (s *userApi) InviteUser(req *InviteUserRequest) (res *StatusResponse, err error) {
-	if req.Email == "" {
-		return nil, fmt.Errorf("email is required")
-	}
+func (s *userApi) InviteUser(ctx context.Context, req *InviteUserRequest) (res *StatusResponse, err error) {
+	db := s.db.WithContext(ctx)
+	req.Email = strings.TrimSpace(req.Email)
 
-	if req.RoleID == 0 {
-		return nil, fmt.Errorf("roleId is required")
+	settings, err := orgsvc.LoadSettings(db, req.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if req.RoleID == 0 && settings.DefaultRoleID != nil {
+		req.RoleID = *settings.DefaultRoleID
 	}
 
-	if req.OrgID == 0 {
-		return nil, fmt.Errorf("orgId is required")
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
 	if req.CurrentUserID == 0 {
-		return nil, fmt.Errorf("currentUserId is required")
+		return nil, helper.Unauthorized("unauthorized", "currentUserId is required")
 	}
 
 	if req.CurrentRoleID == 0 {
-		return nil, fmt.Errorf("currentRoleId is required")
+		return nil, helper.Forbidden("org_access_denied", "currentRoleId is required")
 	}
 
-	req.Email = strings.TrimSpace(req.Email)
+	// Owners can grant any role, admins any but owner, others neither
+	if req.RoleID == 1 && req.CurrentRoleID != 1 {
+		return nil, helper.Forbidden("permission_denied", "only owners can invite owners")
+	}
+	if req.RoleID == 2 && req.CurrentRoleID != 1 && req.CurrentRoleID != 2 {
+		return nil, helper.Forbidden("permission_denied", "only owners and admins can invite admins")
+	}
 
 	var userOrgCount int64
-	result := s.db.Table(orgsvc.UserOrgRoleTableName).
+	result := db.Table(orgsvc.UserOrgRoleTableName).
 		Joins("LEFT JOIN users AS u ON u.id=user_org_roles.user_id").
 		Where("u.email = ? AND user_org_roles.org_id = ? AND user_org_roles.status = ?", req.Email, req.OrgID, UserStatusActive).
 		Count(&userOrgCount)
@@ -285,37 +390,29 @@ func (s *userApi) InviteUser(req *InviteUserRequest) (res *StatusResponse, err e
 	}
 
 	if userOrgCount > 0 {
-		return nil, fmt.Errorf("user already has an active role in this organization")
+		return nil, helper.Conflict("already_member", "user already has an active role in this organization")
 	}
 
 	var org orgsvc.Org
-	result = s.db.Table(orgsvc.OrgTableName).Where("id = ?", req.OrgID).First(&org)
+	result = db.Table(orgsvc.OrgTableName).Where("id = ?", req.OrgID).First(&org)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "org_not_found", "org not found")
 	}
 
 	var cUser User
-	result = s.db.Table(UserTableName).Where("id = ?", req.CurrentUserID).First(&cUser)
+	result = db.Table(UserTableName).Where("id = ?", req.CurrentUserID).First(&cUser)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, helper.NotFoundIfMissing(result.Error, "user_not_found", "current user not found")
 	}
 
 	status := UserStatusPending
 	active := false
 
-	if req.CurrentRoleID == 1 || req.CurrentRoleID == 2 {
+	if (req.CurrentRoleID == 1 || req.CurrentRoleID == 2) && settings.JoinPolicy != orgsvc.JoinPolicyApprovalRequired {
 		status = UserStatusActive
 		active = true
 	}
-
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-	claims["email"] = req.Email
-	claims["orgId"] = req.OrgID
-	claims["roleId"] = req.RoleID
-	claims["status"] = status
-
+	expiresAt := time.Now().Add(settings.InvitationTTL(s.cfg.Tokens.InvitationTTL))
 
 	firstName := ""
 	lastName := ""
@@ -325,97 +422,113 @@ func (s *userApi) InviteUser(req *InviteUserRequest) (res *StatusResponse, err e
 	}
 
 	var user User
-	// Check if user exists
-	result = s.db.Where("email = ?", req.Email).First(&user)
-	if result.Error != nil {
-		log.Error("user not found")
-	}
-
-	currentUserFullName := cUser.FirstName + " " + cUser.LastName
-	claims["currentUserFullName"] = currentUserFullName
-
-	t, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		return nil, err
-	}
-
-	if user.ID == 0 {
-		// if err := handleTotalUsersLimit(s.db, req.OrgID); err != nil {
-		// 	return nil, err
-		// }
-
-		// if err := handleAdminRoleLimit(s.db, req.OrgID); err != nil {
-		// 	return nil, err
-		// }
-
-		// if err := handleAdvisorRoleLimit(s.db, req.OrgID); err != nil {
-		// 	return nil, err
-		// }
-
-		// Generate hash pw
-		pwd := helper.RandomString(8)
-		pwh, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
+	var token string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Create the user if the email is new. ON CONFLICT keeps two concurrent
+		// invites for the same email from failing or creating two users.
+		var err error
+		user, err = firstOrCreateUserByEmail(tx, req.Email, func() (*User, error) {
+			// if err := handleTotalUsersLimit(tx, req.OrgID); err != nil {
+			// 	return nil, err
+			// }
+
+			// if err := handleAdminRoleLimit(tx, req.OrgID); err != nil {
+			// 	return nil, err
+			// }
+
+			// if err := handleAdvisorRoleLimit(tx, req.OrgID); err != nil {
+			// 	return nil, err
+			// }
+
+			// Generate hash pw
+			pwd := helper.RandomString(8)
+			pwh, err := helper.HashPassword(ctx, pwd)
+			if err != nil {
+				return nil, err
+			}
+
+			return &User{
+				Email:         req.Email,
+				Password:      pwh,
+				Active:        active,
+				VerifiedEmail: false,
+			}, nil
+		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		user.Email = req.Email
-		user.Password = string(pwh)
-		user.Active = active
-		user.VerifiedEmail = false
-
-		result = s.db.Omit("UpdatedAt").Create(&user)
+		// Insert the invitation, or re-invite a membership that was rejected or
+		// deactivated. Active memberships and open invitations are left as-is.
+		result := tx.Exec(`INSERT INTO `+orgsvc.UserOrgRoleTableName+` (user_id, org_id, role_id, status)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id, org_id) DO UPDATE
+			SET role_id = EXCLUDED.role_id, status = EXCLUDED.status
+			WHERE user_org_roles.status NOT IN (?, ?)`,
+			user.ID, req.OrgID, req.RoleID, UserStatusInvited,
+			UserStatusActive, UserStatusInvited)
 		if result.Error != nil {
-			return nil, result.Error
+			return result.Error
 		}
-	}
 
-	// Prevent relationship with status=invited creation if exists
-	var userOrgRoleCount int64
-	result = s.db.Table("user_org_roles").
-		Joins("LEFT JOIN users ON users.id = user_org_roles.user_id").
-		Where("users.id = ? AND user_org_roles.org_id = ? AND user_org_roles.status = ?", 
-			user.ID, req.OrgID, UserStatusInvited).
-    	Count(&userOrgRoleCount)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	if userOrgRoleCount > 0 {
-		return nil, fmt.Errorf("user already has already been invited to this organization")
-	}
-
-	if userOrgRoleCount == 0 {
-		userOrgRole := orgsvc.UserOrgRole{
-			UserID: user.ID,
-			OrgID: req.OrgID,
-			RoleID: req.RoleID,
-			Status: UserStatusInvited,
+		if result.RowsAffected == 0 {
+			var existing orgsvc.UserOrgRole
+			if err := tx.Where("user_id = ? AND org_id = ?", user.ID, req.OrgID).First(&existing).Error; err != nil {
+				return err
+			}
+			if existing.Status == UserStatusActive {
+				return helper.Conflict("already_member", "user already has an active role in this organization")
+			}
+			return helper.Conflict("already_invited", "user has already been invited to this organization")
 		}
 
-		result = s.db.Create(&userOrgRole)
-		if result.Error != nil {
-			return nil, result.Error
+		// Tokens of an earlier, rejected or deactivated, invitation must not
+		// revive the membership.
+		if err := tx.Where("purpose = ? AND email = ? AND org_id = ? AND consumed_at IS NULL", tokens.PurposeInvitation, user.Email, req.OrgID).
+			Delete(&tokens.Token{}).Error; err != nil {
+			return err
 		}
 
+		// What accepting grants lives in the token record, never in the link.
+		orgID, roleID, userID, createdBy := req.OrgID, req.RoleID, user.ID, cUser.ID
+		token, err = tokens.Issue(tx, &tokens.Token{
+			Purpose:   tokens.PurposeInvitation,
+			Email:     user.Email,
+			UserID:    &userID,
+			OrgID:     &orgID,
+			RoleID:    &roleID,
+			Status:    status,
+			CreatedBy: &createdBy,
+			ExpiresAt: expiresAt,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	metrics.InvitationCreated()
+	s.logger.Info("invitation created", "org_id", req.OrgID, "invited_user_id", user.ID, "role_id", req.RoleID, "email", req.Email)
 
 	m := gomail.NewMessage()	
-		m.SetHeader("From", "influxoks@gmail.com")
+		m.SetHeader("From", s.cfg.Mail.From)
 		m.SetHeader("To", user.Email)
 		m.SetHeader("Subject", "Vezhguesi: You're invited to join " + org.Name)
-		m.SetBody("text/html", fmt.Sprintf(`You've received an invitation!<br/><br/>
+		m.SetBody("text/html", brandedEmail(settings, fmt.Sprintf(`You've received an invitation!<br/><br/>
 
 			%s has invited you to join the Organization %s.<br/>
 			In order to access this Organization you must click the link below and continue registration: <br/><br/>
 
-			<a href='%s'>Accept Invitation</a><br/><br/>
+			%s<br/><br/>
+
+			This invitation expires on %s.<br/><br/>
 
 			Thank you, <br/>
 			Vezhguesi Team
-		`, fullName, org.Name, fmt.Sprintf(`%s/accept-invitation/%s`, s.uiAppUrl, t)))
+		`, fullName, org.Name,
+			emailButton(settings, fmt.Sprintf(`%s/accept-invitation/%s`, s.cfg.UIAppURL, token), "Accept Invitation"),
+			expiresAt.In(settings.Location()).Format("January 2, 2006 15:04 MST"))))
 
-		err = s.dialer.DialAndSend(m)
+		err = s.sendMail(ctx, mailTemplateInvitation, m)
 		if err != nil {
 			return nil, err
 	}
@@ -424,7 +537,7 @@ func (s *userApi) InviteUser(req *InviteUserRequest) (res *StatusResponse, err e
 }
 
 // @Summary      	InviteAccept
-// @Description		Validates token, username, firstName, lastName, password and confirmPassword, then check in DB if user with same email and org is already connected if not creates User, Profile and Org Relationship and returns created user ID in response.
+// @Description		Validates token, username, firstName, lastName, password and confirmPassword, then consumes the single-use invitation token, completes the invited user's registration, sends an email verification link and activates the invited membership with the role and status stored for it. A token can only be accepted once. If the invited email already has an account, the invitation must be accepted while signed in instead.
 // @Tags			Users
 // @Accept			json
 // @Produce			json
@@ -432,127 +545,738 @@ func (s *userApi) InviteUser(req *InviteUserRequest) (res *StatusResponse, err e
 // @Param			AcceptInvitationRequest	body		AcceptInvitationRequest	true	"AcceptInvitationRequest"
 // @Success			200					{object}	AcceptInvitationResponse
 // @Router			/api/users/invite/accept/{token}	[POST]
-func (s *userApi) AcceptInvitation(req *AcceptInvitationRequest) (*AcceptInvitationResponse, error) {
-	if req.Token == "" {
-		return nil, fmt.Errorf("token is required")
+func (s *userApi) AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*AcceptInvitationResponse, error) {
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
 	}
 
-	// Validate password match
-	if req.Password != req.ConfirmPassword {
-		return nil, fmt.Errorf("passwords do not match")
-	}
+	var registered User
+	res, err := s.acceptInvitation(ctx, req.Token, func(tx *gorm.DB, user *User) error {
+		// The password would overwrite the one of an account the caller has
+		// not proven to own.
+		if user.registered() {
+			return helper.Conflict("account_exists", "an account with this email already exists, sign in to accept the invitation")
+		}
+
+		hashedPassword, err := helper.HashPassword(ctx, req.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %v", err)
+		}
 
-	// Parse and validate token
-	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(req.Token, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		user.Username = &req.UserName
+		user.Password = hashedPassword
+		user.FirstName = req.FirstName
+		user.LastName = req.LastName
+		user.Status = UserStatusActive
+		user.Active = true
+		user.VerifiedEmail = false
+		if err := tx.Save(user).Error; err != nil {
+			if _, ok := helper.UniqueViolation(err); ok {
+				return helper.Conflict("username_taken", "username is already taken")
+			}
+			return err
+		}
+		registered = *user
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	email := fmt.Sprintf("%v", claims["email"])
-	orgIdStr := fmt.Sprintf("%v", claims["orgId"])
-	roleIdStr := fmt.Sprintf("%v", claims["roleId"])
-	status := fmt.Sprintf("%v", claims["status"])
+	// The account exists either way; the user can ask for another link.
+	if err := s.sendVerificationEmail(ctx, registered); err != nil {
+		s.logger.Warn("failed to send verification email", "user_id", registered.ID, "error", err)
+	}
+	return res, nil
+}
 
-	orgId, err := strconv.Atoi(orgIdStr)
-	if err != nil {
+// @Summary      	AcceptInvitationAsUser
+// @Description		Consumes the single-use invitation token and adds the signed-in user to the org with the role and status stored for it. The invitation must have been sent to the signed-in user's email.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization		header		string	true	"Authorization Key(e.g Bearer key)"
+// @Param			token				path		string	true	"Token"
+// @Success			200					{object}	AcceptInvitationResponse
+// @Router			/api/invitations/{token}/accept	[POST]
+func (s *userApi) AcceptInvitationAsUser(ctx context.Context, req *AcceptInvitationAsUserRequest) (*AcceptInvitationResponse, error) {
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return s.acceptInvitation(ctx, req.Token, func(tx *gorm.DB, user *User) error {
+		if user.ID != req.CurrentUserID {
+			return helper.Forbidden("invitation_email_mismatch", "invitation was sent to a different email address")
+		}
+		return nil
+	})
+}
+
+// @Summary      	GetInvitation
+// @Description		Returns what the UI shows before accepting an invitation: the org, who sent it, the invited email and whether the invited user still has to sign up. The token is not consumed.
+// @Tags			Users
+// @Produce			json
+// @Param			token				path		string	true	"Token"
+// @Success			200					{object}	InvitationResponse
+// @Router			/api/invitations/{token}	[GET]
+func (s *userApi) GetInvitation(ctx context.Context, req *InvitationRequest) (*InvitationResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
 		return nil, err
 	}
 
-	roleId, err := strconv.Atoi(roleIdStr)
+	invitation, err := tokens.Find(db, tokens.PurposeInvitation, req.Token)
+	if errors.Is(err, tokens.ErrInvalid) || (err == nil && !validInvitation(invitation)) {
+		return nil, helper.ValidationError("invalid_invitation", "invitation token is invalid or expired")
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if user exists
+	var org orgsvc.Org
+	if err := db.Where("id = ?", *invitation.OrgID).First(&org).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "org_not_found", "org not found")
+	}
+
 	var user User
-	result := s.db.Where("email = ?", email).First(&user)
-	
-	if result.Error != nil {
-		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	if err := db.Where("id = ?", *invitation.UserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+
+	invitedBy := ""
+	if invitation.CreatedBy != nil {
+		var inviter User
+		result := db.Where("id = ?", *invitation.CreatedBy).Limit(1).Find(&inviter)
+		if result.Error != nil {
 			return nil, result.Error
 		}
-		
-		// Create new user if not found
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		invitedBy = strings.TrimSpace(inviter.FirstName + " " + inviter.LastName)
+	}
+
+	return &InvitationResponse{
+		Email:          invitation.Email,
+		OrgName:        org.Name,
+		OrgSlug:        org.Slug,
+		InvitedBy:      invitedBy,
+		RoleID:         *invitation.RoleID,
+		ExpiresAt:      invitation.ExpiresAt,
+		SignupRequired: !user.registered(),
+	}, nil
+}
+
+// @Summary      	VerifyEmail
+// @Description		Consumes the single-use token from a verification email and marks the user's email as verified. The token only works while the user still has the email it was sent to.
+// @Tags			Users
+// @Produce			json
+// @Param			token				path		string	true	"Token"
+// @Success			200					{object}	StatusResponse
+// @Router			/api/users/verify-email/{token}	[POST]
+func (s *userApi) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		verification, err := tokens.Consume(tx, tokens.PurposeEmailVerification, req.Token)
+		if errors.Is(err, tokens.ErrInvalid) || (err == nil && verification.UserID == nil) {
+			return helper.ValidationError("invalid_verification_token", "verification token is invalid or expired")
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to hash password: %v", err)
+			return err
 		}
 
-		user = User{
-			Email:         email,
-			Username:      &req.UserName,
-			Password:      string(hashedPassword),
-			FirstName:     req.FirstName,
-			LastName:      req.LastName,
-			Status:        "active",
-			Active:        true,
-			VerifiedEmail: true,
+		// A link sent before an email change must not verify the new address.
+		result := tx.Table(UserTableName).
+			Where("id = ? AND email = ?", *verification.UserID, verification.Email).
+			Update("verified_email", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.ValidationError("invalid_verification_token", "verification token is invalid or expired")
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		result = s.db.Create(&user)
-		if result.Error != nil {
-			return nil, fmt.Errorf("failed to create user: %v", result.Error)
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	ResendVerificationEmail
+// @Description		Sends the current user a new email verification link. Links sent earlier stop working.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization		header		string	true	"Authorization Key(e.g Bearer key)"
+// @Success			200					{object}	StatusResponse
+// @Router			/api/users/verify-email/resend	[POST]
+func (s *userApi) ResendVerificationEmail(ctx context.Context, req *ResendVerificationRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+	if user.VerifiedEmail {
+		return nil, helper.Conflict("email_already_verified", "email is already verified")
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	GetMe
+// @Description		Returns the profile of the current user.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization		header		string	true	"Authorization Key(e.g Bearer key)"
+// @Success			200					{object}	ProfileResponse
+// @Router			/api/users/me	[GET]
+func (s *userApi) GetMe(ctx context.Context, req *MeRequest) (*ProfileResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+
+	return s.profileResponse(ctx, user)
+}
+
+// @Summary      	UpdateMe
+// @Description		Updates the username, first name, last name and phone of the current user. Fields left out of the request are not changed.
+// @Tags			Users
+// @Accept			json
+// @Produce			json
+// @Param			Authorization			header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			UpdateProfileRequest	body		UpdateProfileRequest	true	"UpdateProfileRequest"
+// @Success			200						{object}	ProfileResponse
+// @Router			/api/users/me	[PATCH]
+func (s *userApi) UpdateMe(ctx context.Context, req *UpdateProfileRequest) (*ProfileResponse, error) {
+	db := s.db.WithContext(ctx)
+	trimSpace(req.FirstName, req.LastName, req.Phone)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Username != nil {
+		updates["username"] = *req.Username
+		user.Username = req.Username
+	}
+	if req.FirstName != nil {
+		updates["first_name"] = *req.FirstName
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		updates["last_name"] = *req.LastName
+		user.LastName = *req.LastName
+	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+		user.Phone = *req.Phone
+	}
+	if len(updates) == 0 {
+		return s.profileResponse(ctx, user)
+	}
+
+	if err := db.Table(UserTableName).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		if _, ok := helper.UniqueViolation(err); ok {
+			return nil, helper.Conflict("username_taken", "username is already taken")
 		}
+		return nil, err
 	}
 
-	// Update user-org relationship
-	var userOrgRole orgsvc.UserOrgRole
-	result = s.db.Where("user_id = ? AND org_id = ?", user.ID, orgId).First(&userOrgRole)
-	if result.Error != nil {
-		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
+	return s.profileResponse(ctx, user)
+}
+
+// @Summary      	ChangePassword
+// @Description		Checks the current password of the current user and replaces it with the new one.
+// @Tags			Users
+// @Accept			json
+// @Produce			json
+// @Param			Authorization			header		string					true	"Authorization Key(e.g Bearer key)"
+// @Param			ChangePasswordRequest	body		ChangePasswordRequest	true	"ChangePasswordRequest"
+// @Success			200						{object}	StatusResponse
+// @Router			/api/users/me/password	[POST]
+func (s *userApi) ChangePassword(ctx context.Context, req *ChangePasswordRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+	if !helper.CheckPassword(ctx, user.Password, req.CurrentPassword) {
+		return nil, invalidCurrentPassword("currentPassword")
+	}
+
+	hashedPassword, err := helper.HashPassword(ctx, req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+	if err := db.Table(UserTableName).Where("id = ?", user.ID).Update("password", hashedPassword).Error; err != nil {
+		return nil, err
+	}
+	s.logger.Info("password changed", "user_id", user.ID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	ChangeEmail
+// @Description		Checks the password of the current user and sends a confirmation link to the new email address. The email is only changed once the link is used.
+// @Tags			Users
+// @Accept			json
+// @Produce			json
+// @Param			Authorization		header		string				true	"Authorization Key(e.g Bearer key)"
+// @Param			ChangeEmailRequest	body		ChangeEmailRequest	true	"ChangeEmailRequest"
+// @Success			200					{object}	StatusResponse
+// @Router			/api/users/me/email	[POST]
+func (s *userApi) ChangeEmail(ctx context.Context, req *ChangeEmailRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	req.NewEmail = strings.TrimSpace(req.NewEmail)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+	if !helper.CheckPassword(ctx, user.Password, req.Password) {
+		return nil, invalidCurrentPassword("password")
+	}
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		return nil, helper.Conflict("email_unchanged", "email is already set to the new email")
+	}
+
+	var taken int64
+	if err := db.Table(UserTableName).Where("email = ?", req.NewEmail).Count(&taken).Error; err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		return nil, helper.Conflict("email_taken", "email is already in use")
+	}
+
+	var token string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Only the latest requested address can be confirmed.
+		if err := tx.Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", tokens.PurposeEmailChange, user.ID).
+			Delete(&tokens.Token{}).Error; err != nil {
+			return err
+		}
+
+		userID := user.ID
+		var err error
+		token, err = tokens.Issue(tx, &tokens.Token{
+			Purpose:   tokens.PurposeEmailChange,
+			Email:     req.NewEmail,
+			UserID:    &userID,
+			ExpiresAt: time.Now().Add(s.cfg.Tokens.EmailVerificationTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Mail.From)
+	m.SetHeader("To", req.NewEmail)
+	m.SetHeader("Subject", "Vezhguesi: Confirm your new email address")
+	m.SetBody("text/html", fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
+			You asked to change the email address of your account to this one. Click the link below to confirm: <br/><br/>
+
+			<a href='%s'>Confirm Email</a><br/><br/>
+
+			If you did not ask for this, you can ignore this email.<br/><br/>
+
+			Thank you, <br/>
+			Vezhguesi Team
+		`, fmt.Sprintf(`%s/confirm-email/%s`, s.cfg.UIAppURL, token)))
+
+	if err := s.sendMail(ctx, mailTemplateEmailChange, m); err != nil {
+		return nil, err
+	}
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	ConfirmEmailChange
+// @Description		Consumes the single-use token from an email change link and sets the user's email to the address it was sent to, which is then verified.
+// @Tags			Users
+// @Produce			json
+// @Param			token				path		string	true	"Token"
+// @Success			200					{object}	StatusResponse
+// @Router			/api/users/confirm-email/{token}	[POST]
+func (s *userApi) ConfirmEmailChange(ctx context.Context, req *ConfirmEmailChangeRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var userID int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		change, err := tokens.Consume(tx, tokens.PurposeEmailChange, req.Token)
+		if errors.Is(err, tokens.ErrInvalid) || (err == nil && change.UserID == nil) {
+			return helper.ValidationError("invalid_verification_token", "verification token is invalid or expired")
 		}
-		
-		// Create new user-org relationship if not found
-		userOrgRole = orgsvc.UserOrgRole{
-			UserID: user.ID,
-			OrgID:  orgId,
-			RoleID: roleId,
-			Status: status,
+		if err != nil {
+			return err
 		}
-		
-		result = s.db.Create(&userOrgRole)
+		userID = *change.UserID
+
+		result := tx.Table(UserTableName).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"email": change.Email, "verified_email": true})
 		if result.Error != nil {
-			return nil, fmt.Errorf("failed to create user-org relationship: %v", result.Error)
+			if _, ok := helper.UniqueViolation(result.Error); ok {
+				return helper.Conflict("email_taken", "email is already in use")
+			}
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.ValidationError("invalid_verification_token", "verification token is invalid or expired")
+		}
+
+		// Links sent to the old address must not verify it again.
+		return tx.Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", tokens.PurposeEmailVerification, userID).
+			Delete(&tokens.Token{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("email changed", "user_id", userID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// @Summary      	UploadAvatar
+// @Description		Replaces the avatar of the current user. Accepts a JPEG, PNG, GIF or WebP image in the avatar form field, between 64 and 4096 pixels wide and high, and stores square thumbnails of it. Returns the profile with signed URLs of the thumbnails.
+// @Tags			Users
+// @Accept			multipart/form-data
+// @Produce			json
+// @Param			Authorization		header		string	true	"Authorization Key(e.g Bearer key)"
+// @Param			avatar				formData	file	true	"Image"
+// @Success			200					{object}	ProfileResponse
+// @Router			/api/users/me/avatar	[PUT]
+func (s *userApi) UploadAvatar(ctx context.Context, req *UploadAvatarRequest) (*ProfileResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+
+	thumbnails, err := avatars.Process(ctx, req.Image)
+	if err != nil {
+		return nil, err
+	}
+	key, err := avatars.NewKey(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := avatars.Save(ctx, s.blobs, key, thumbnails); err != nil {
+		return nil, fmt.Errorf("failed to store avatar: %w", err)
+	}
+
+	oldKey := user.AvatarImgKey
+	if err := db.Table(UserTableName).Where("id = ?", user.ID).Update("avatar_img_key", key).Error; err != nil {
+		s.deleteAvatar(ctx, key)
+		return nil, err
+	}
+	user.AvatarImgKey = key
+	s.deleteAvatar(ctx, oldKey)
+
+	return s.profileResponse(ctx, user)
+}
+
+// @Summary      	DeleteAvatar
+// @Description		Removes the avatar of the current user.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization		header		string	true	"Authorization Key(e.g Bearer key)"
+// @Success			200					{object}	ProfileResponse
+// @Router			/api/users/me/avatar	[DELETE]
+func (s *userApi) DeleteAvatar(ctx context.Context, req *MeRequest) (*ProfileResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := db.Where("id = ?", req.CurrentUserID).First(&user).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "user_not_found", "user not found")
+	}
+	if user.AvatarImgKey == "" {
+		return s.profileResponse(ctx, user)
+	}
+
+	if err := db.Table(UserTableName).Where("id = ?", user.ID).Update("avatar_img_key", "").Error; err != nil {
+		return nil, err
+	}
+	s.deleteAvatar(ctx, user.AvatarImgKey)
+	user.AvatarImgKey = ""
+
+	return s.profileResponse(ctx, user)
+}
+
+// acceptInvitation consumes the invitation token and activates the membership
+// it was issued for. claim runs in the same transaction with the invited user
+// and decides whether the caller may accept for them; an error from it leaves
+// the token unused.
+func (s *userApi) acceptInvitation(ctx context.Context, token string, claim func(tx *gorm.DB, user *User) error) (*AcceptInvitationResponse, error) {
+	db := s.db.WithContext(ctx)
+
+	var invitation *tokens.Token
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Consuming the token first makes a second accept with the same link
+		// fail, even when both run at the same time.
+		var err error
+		invitation, err = tokens.Consume(tx, tokens.PurposeInvitation, token)
+		if errors.Is(err, tokens.ErrInvalid) || (err == nil && !validInvitation(invitation)) {
+			return helper.ValidationError("invalid_invitation", "invitation token is invalid or expired")
+		}
+		if err != nil {
+			return err
+		}
+
+		var user User
+		if err := tx.Where("id = ?", *invitation.UserID).First(&user).Error; err != nil {
+			return err
 		}
-	} else {
-		// Update existing relationship
-		userOrgRole.Status = status
-		result = s.db.Save(&userOrgRole)
+		if err := claim(tx, &user); err != nil {
+			return err
+		}
+
+		// The membership must still be the open invitation the token was
+		// issued for; an admin may have removed or changed it since.
+		result := tx.Table(orgsvc.UserOrgRoleTableName).
+			Where("user_id = ? AND org_id = ? AND status = ?", user.ID, *invitation.OrgID, UserStatusInvited).
+			Updates(map[string]interface{}{"role_id": *invitation.RoleID, "status": invitation.Status})
 		if result.Error != nil {
-			return nil, fmt.Errorf("failed to update user-org relationship: %v", result.Error)
+			return fmt.Errorf("failed to save user-org relationship: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return helper.ValidationError("invalid_invitation", "invitation token is invalid or expired")
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Get org slug for response
 	var org orgsvc.Org
-	result = s.db.Where("id = ?", orgId).First(&org)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to get org: %v", result.Error)
+	if err := db.Where("id = ?", *invitation.OrgID).First(&org).Error; err != nil {
+		return nil, helper.NotFoundIfMissing(err, "org_not_found", "org not found")
 	}
 
-	token := ""
-	if roleId == 5 || roleId == 6 || roleId == 7 {
-		token = req.Token
-	}
+	metrics.InvitationAccepted()
 
 	return &AcceptInvitationResponse{
 		InviteAccepted: true,
 		OrgSlug:        org.Slug,
-		Token:          token,
-		Status:         status,
-		RoleID:         roleId,
+		Status:         invitation.Status,
+		RoleID:         *invitation.RoleID,
 	}, nil
 }
 
+// Private helper funcs
+
+func (s *userApi) sendMail(ctx context.Context, template string, m *gomail.Message) error {
+	_, span := tracing.Start(ctx, "smtp.DialAndSend",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mail.template", template),
+			semconv.ServerAddress(s.dialer.Host),
+			semconv.ServerPort(s.dialer.Port),
+		),
+	)
+	err := s.dialer.DialAndSend(m)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.EmailFailed(template)
+		s.logger.Error("failed to send email", "template", template, "to", m.GetHeader("To"), "error", err)
+		return err
+	}
+	metrics.EmailSent(template)
+	return nil
+}
+
+// sendVerificationEmail mails user a link that verifies their current email.
+// Earlier links for the user are revoked, so only the latest one works.
+func (s *userApi) sendVerificationEmail(ctx context.Context, user User) error {
+	var token string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", tokens.PurposeEmailVerification, user.ID).
+			Delete(&tokens.Token{}).Error; err != nil {
+			return err
+		}
+
+		userID := user.ID
+		var err error
+		token, err = tokens.Issue(tx, &tokens.Token{
+			Purpose:   tokens.PurposeEmailVerification,
+			Email:     user.Email,
+			UserID:    &userID,
+			ExpiresAt: time.Now().Add(s.cfg.Tokens.EmailVerificationTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Mail.From)
+	m.SetHeader("To", user.Email)
+	m.SetHeader("Subject", "Vezhguesi: Verify your email address")
+	m.SetBody("text/html", fmt.Sprintf(`Hello from Vezhguesi!<br/><br/>
+			Please confirm that this is your email address by clicking the link below: <br/><br/>
 
+			<a href='%s'>Verify Email</a><br/><br/>
 
+			If you did not create an account, you can ignore this email.<br/><br/>
 
+			Thank you, <br/>
+			Vezhguesi Team
+		`, fmt.Sprintf(`%s/verify-email/%s`, s.cfg.UIAppURL, token)))
+
+	return s.sendMail(ctx, mailTemplateVerifyEmail, m)
+}
+
+// brandedEmail puts the org's logo above an email body.
+func brandedEmail(settings orgsvc.OrgSettings, body string) string {
+	if settings.LogoURL == "" {
+		return body
+	}
+	return fmt.Sprintf(`<img src='%s' alt='' style='max-height:48px'/><br/><br/>`, html.EscapeString(settings.LogoURL)) + body
+}
+
+// emailButton links to href, styled in the org's primary color if it has one.
+func emailButton(settings orgsvc.OrgSettings, href, label string) string {
+	if settings.PrimaryColor == "" {
+		return fmt.Sprintf(`<a href='%s'>%s</a>`, href, label)
+	}
+	return fmt.Sprintf(`<a href='%s' style='display:inline-block;padding:10px 16px;border-radius:4px;color:#ffffff;text-decoration:none;background-color:%s'>%s</a>`,
+		href, settings.PrimaryColor, label)
+}
+
+// firstOrCreateUserByEmail returns the user with the given email, creating it
+// from newUser() if there is none. Concurrent callers for the same email end
+// up with the same row: the loser of the insert race reads the winner's user.
+func firstOrCreateUserByEmail(tx *gorm.DB, email string, newUser func() (*User, error)) (User, error) {
+	var user User
+	result := tx.Where("email = ?", email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return user, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return user, nil
+	}
+
+	created, err := newUser()
+	if err != nil {
+		return user, err
+	}
+
+	result = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoNothing: true,
+	}).Create(created)
+	if result.Error != nil {
+		if _, ok := helper.UniqueViolation(result.Error); ok {
+			return user, helper.Conflict("username_taken", "username is already taken")
+		}
+		return user, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return *created, nil
+	}
+
+	if err := tx.Where("email = ?", email).First(&user).Error; err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// deleteAvatar removes the files of an avatar that is no longer used. A
+// failure only leaves unreferenced files behind, so it is logged.
+func (s *userApi) deleteAvatar(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := avatars.Delete(ctx, s.blobs, key); err != nil {
+		s.logger.Warn("failed to delete avatar files", "key", key, "error", err)
+	}
+}
+
+// profileResponse describes user, with signed URLs of their avatar.
+func (s *userApi) profileResponse(ctx context.Context, user User) (*ProfileResponse, error) {
+	avatarURLs, err := avatars.URLs(ctx, s.blobs, user.AvatarImgKey, s.cfg.Storage.SignedURLTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	username := ""
+	if user.Username != nil {
+		username = *user.Username
+	}
+	return &ProfileResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		Username:      username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Phone:         user.Phone,
+		AvatarImgKey:  user.AvatarImgKey,
+		AvatarURLs:    avatarURLs,
+		VerifiedEmail: user.VerifiedEmail,
+	}, nil
+}
+
+// invalidCurrentPassword is returned when the password a user confirms a
+// change with is wrong. Its code counts towards the lockout.
+func invalidCurrentPassword(field string) error {
+	return helper.ValidationError("invalid_current_password", field+" is incorrect", helper.FieldError{Field: field, Message: "is incorrect"})
+}
+
+// trimSpace trims the strings that are set.
+func trimSpace(fields ...*string) {
+	for _, f := range fields {
+		if f != nil {
+			*f = strings.TrimSpace(*f)
+		}
+	}
+}
+
+// validInvitation reports whether t has everything accepting an invitation
+// needs.
+func validInvitation(t *tokens.Token) bool {
+	return t.UserID != nil && t.OrgID != nil && t.RoleID != nil
+}
 
-// Private helper funcs
 func handleTotalUsersLimit(db *gorm.DB, orgId int) error {
 	var totalUserCount int64
 	result := db.Table("user_org_roles").
@@ -577,7 +1301,7 @@ func handleTotalUsersLimit(db *gorm.DB, orgId int) error {
 	}
 
 	if int(totalUserCount) >= totalUserLimit {
-		return fmt.Errorf("user creation has reached limit, consider upgrading your plan.")
+		return helper.LimitExceeded("members_limit", "user creation has reached limit, consider upgrading your plan.")
 	}
 	return nil
 }
@@ -605,7 +1329,7 @@ func handleAdminRoleLimit(db *gorm.DB, orgId int) error {
 		return err
 	}
 	if int(adminUserCount) >= adminRoleLimit {
-		return fmt.Errorf("User admin roles has reached limit, consider upgrading your plan.")
+		return helper.LimitExceeded("admin_role_limit", "User admin roles has reached limit, consider upgrading your plan.")
 	}
 	return nil
 }
@@ -633,7 +1357,146 @@ func handleAdvisorRoleLimit(db *gorm.DB, orgId int) error {
 		return err
 	}
 	if int(advisorUserCount) >= advisorRoleLimit {
-		return fmt.Errorf("User mentor roles has reached limit, consider upgrading your plan.")
+		return helper.LimitExceeded("mentor_role_limit", "User mentor roles has reached limit, consider upgrading your plan.")
 	}
 	return nil
 }
+
+// signOutOfOrg refuses the member of req every session begun so far, in
+// this org only, and records it in the org's audit log. Their sessions in
+// other orgs are untouched; a platform admin suspends the account to sign
+// them out everywhere.
+func signOutOfOrg(tx *gorm.DB, req *ChangeUserStatusRequest) error {
+	err := tx.Table(orgsvc.UserOrgRoleTableName).
+		Where("org_id = ? AND user_id = ?", req.OrgID, req.UserID).
+		Update("sessions_revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	orgID := req.OrgID
+	event := &audit.Event{
+		OrgID:      &orgID,
+		Action:     AuditActionSessionsRevoked,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(req.UserID),
+		Details:    map[string]interface{}{"status": req.Status},
+	}
+	if req.CurrentUserID != 0 {
+		actorID := req.CurrentUserID
+		event.ActorUserID = &actorID
+	}
+	if req.APIKeyID != 0 {
+		apiKeyID := req.APIKeyID
+		event.ActorAPIKeyID = &apiKeyID
+	}
+	return audit.Record(tx, event)
+}
+
+// @Summary      	ListSessions
+// @Description		Lists the current user's sessions that are neither signed out nor expired, most recently active first, with the device, IP address and last activity of each. Tokens of one sign-in are told apart by their sid claim; a token without one is listed as a session of its own. Impersonation tokens get a 403 sessions_unsupported.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization			header		string				true	"Authorization Key(e.g Bearer key)"
+// @Success			200						{object}	SessionsResponse
+// @Router			/api/users/me/sessions	[GET]
+func (s *userApi) ListSessions(ctx context.Context, req *SessionsRequest) (*SessionsResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+	// Impersonation tokens belong to the acting superadmin, not the user
+	if req.CurrentSessionID == 0 {
+		return nil, helper.Forbidden("sessions_unsupported", "Session management needs the user's own access token")
+	}
+
+	var sessions []Session
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", req.CurrentUserID, time.Now()).
+		Order("last_active_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	res := &SessionsResponse{Sessions: make([]SessionResponse, 0, len(sessions))}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, SessionResponse{
+			ID:           session.ID,
+			Device:       describeDevice(session.UserAgent),
+			UserAgent:    session.UserAgent,
+			IP:           session.IP,
+			CreatedAt:    session.CreatedAt,
+			LastActiveAt: session.LastActiveAt,
+			ExpiresAt:    session.ExpiresAt,
+			Current:      session.ID == req.CurrentSessionID,
+		})
+	}
+	return res, nil
+}
+
+// @Summary      	RevokeSession
+// @Description		Signs the current user out of one of their sessions; its tokens are rejected from then on. The current session can be revoked too. Not available to impersonation tokens.
+// @Tags			Users
+// @Produce			json
+// @Param			Authorization			header		string				true	"Authorization Key(e.g Bearer key)"
+// @Param			sessionId				path		int					true	"Session ID"
+// @Success			200						{object}	StatusResponse
+// @Router			/api/users/me/sessions/{sessionId}	[DELETE]
+func (s *userApi) RevokeSession(ctx context.Context, req *RevokeSessionRequest) (*StatusResponse, error) {
+	db := s.db.WithContext(ctx)
+	if err := s.validate.Struct(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.CurrentSessionID == 0 {
+		return nil, helper.Forbidden("sessions_unsupported", "Session management needs the user's own access token")
+	}
+
+	result := db.Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", req.SessionID, req.CurrentUserID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_by": req.CurrentUserID})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, helper.NotFound("session_not_found", "session not found")
+	}
+	s.logger.Info("session revoked", "user_id", req.CurrentUserID, "session_id", req.SessionID)
+
+	return &StatusResponse{Status: true}, nil
+}
+
+// describeDevice turns a User-Agent into a label such as "Chrome on macOS"
+// for the session list. Unknown agents are shown as they are.
+func describeDevice(userAgent string) string {
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	}
+	systems := []struct{ token, name string }{
+		{"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range systems {
+		if strings.Contains(userAgent, o.token) {
+			system = o.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return userAgent
+}
@@ -0,0 +1,167 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"org-service/db/dbtest"
+	"org-service/helper"
+	orgsvc "org-service/org"
+
+	"gorm.io/gorm"
+)
+
+func TestChangeUserStatusOfOwners(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller func(ownerID, adminID int) ChangeUserStatusRequest
+		target func(ownerID, secondOwnerID int) int
+		code   string // expected error code, or "" if allowed
+	}{
+		{"admin deactivates an owner", func(_, adminID int) ChangeUserStatusRequest {
+			return ChangeUserStatusRequest{CurrentUserID: adminID, CurrentRoleID: 2}
+		}, func(ownerID, _ int) int { return ownerID }, "permission_denied"},
+		{"api key deactivates an owner", func(int, int) ChangeUserStatusRequest {
+			return ChangeUserStatusRequest{APIKeyID: 1}
+		}, func(ownerID, _ int) int { return ownerID }, "permission_denied"},
+		{"owner deactivates another owner", func(ownerID, _ int) ChangeUserStatusRequest {
+			return ChangeUserStatusRequest{CurrentUserID: ownerID, CurrentRoleID: 1}
+		}, func(_, secondOwnerID int) int { return secondOwnerID }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, svc, orgID, ownerID := setupInviteTest(t)
+			secondOwnerID := addMember(t, conn, orgID, 1)
+			adminID := addMember(t, conn, orgID, 2)
+
+			req := tt.caller(ownerID, adminID)
+			req.OrgID = orgID
+			req.UserID = tt.target(ownerID, secondOwnerID)
+			req.Status = UserStatusInactive
+			_, err := svc.ChangeUserStatus(context.Background(), &req)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("got %v, want the status changed", err)
+				}
+				return
+			}
+			if !helper.HasCode(err, tt.code) {
+				t.Fatalf("got %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestChangeUserStatusKeepsAnActiveOwner(t *testing.T) {
+	conn, svc, orgID, ownerID := setupInviteTest(t)
+
+	_, err := svc.ChangeUserStatus(context.Background(), &ChangeUserStatusRequest{
+		OrgID:         orgID,
+		UserID:        ownerID,
+		Status:        UserStatusInactive,
+		CurrentUserID: ownerID,
+		CurrentRoleID: 1,
+	})
+	if !helper.HasCode(err, "last_owner") {
+		t.Fatalf("deactivating the only owner: got %v, want last_owner", err)
+	}
+
+	// Two owners deactivating each other at once leave one of them active
+	secondOwnerID := addMember(t, conn, orgID, 1)
+	pairs := [][2]int{{ownerID, secondOwnerID}, {secondOwnerID, ownerID}}
+	errs := make([]error, len(pairs))
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.ChangeUserStatus(context.Background(), &ChangeUserStatusRequest{
+				OrgID:         orgID,
+				UserID:        pair[1],
+				Status:        UserStatusInactive,
+				CurrentUserID: pair[0],
+				CurrentRoleID: 1,
+			})
+		}()
+	}
+	wg.Wait()
+
+	var active int64
+	if err := conn.Table(orgsvc.UserOrgRoleTableName).
+		Where("org_id = ? AND role_id = 1 AND status = ?", orgID, UserStatusActive).
+		Count(&active).Error; err != nil {
+		t.Fatal(err)
+	}
+	if active != 1 {
+		t.Errorf("got %d active owners (errors %v), want 1", active, errs)
+	}
+}
+
+func TestChangeUserRoleKeepsAnActiveOwner(t *testing.T) {
+	_, svc, orgID, ownerID := setupInviteTest(t)
+
+	_, err := svc.ChangeUserRole(context.Background(), &ChangeUserRoleRequest{
+		OrgID:         orgID,
+		UserID:        ownerID,
+		NewRoleID:     2,
+		CurrentUserID: ownerID,
+		CurrentRoleID: 1,
+	})
+	if !helper.HasCode(err, "last_owner") {
+		t.Fatalf("demoting the only owner: got %v, want last_owner", err)
+	}
+}
+
+// addMember adds a new user to orgID as an active member with roleID.
+func addMember(t *testing.T, conn *gorm.DB, orgID, roleID int) int {
+	t.Helper()
+
+	userID := dbtest.CreateUser(t, conn, dbtest.Unique("member")+"@example.com")
+	err := conn.Exec("INSERT INTO "+orgsvc.UserOrgRoleTableName+" (user_id, org_id, role_id, status) VALUES (?, ?, ?, ?)",
+		userID, orgID, roleID, UserStatusActive).Error
+	if err != nil {
+		t.Fatalf("adding member: %v", err)
+	}
+	return userID
+}
+
+func TestChangeUserStatusSignsOutOfThisOrgOnly(t *testing.T) {
+	conn, svc, orgID, ownerID := setupInviteTest(t)
+	memberID := addMember(t, conn, orgID, 3)
+	_, _, otherOrgID, _ := setupInviteTest(t)
+	err := conn.Exec("INSERT INTO "+orgsvc.UserOrgRoleTableName+" (user_id, org_id, role_id, status) VALUES (?, ?, 3, ?)",
+		memberID, otherOrgID, UserStatusActive).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.ChangeUserStatus(context.Background(), &ChangeUserStatusRequest{
+		OrgID:          orgID,
+		UserID:         memberID,
+		Status:         UserStatusInactive,
+		RevokeSessions: true,
+		CurrentUserID:  ownerID,
+		CurrentRoleID:  1,
+	})
+	if err != nil {
+		t.Fatalf("ChangeUserStatus: %v", err)
+	}
+
+	revokedIn := func(orgID int) bool {
+		var revoked bool
+		err := conn.Raw("SELECT sessions_revoked_at IS NOT NULL FROM "+orgsvc.UserOrgRoleTableName+" WHERE org_id = ? AND user_id = ?", orgID, memberID).
+			Scan(&revoked).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revoked
+	}
+	if !revokedIn(orgID) {
+		t.Error("member was not signed out of the org")
+	}
+	if revokedIn(otherOrgID) {
+		t.Error("member was signed out of another org")
+	}
+}
@@ -1,12 +1,13 @@
 package users
 
 import (
+	"io"
+	"log/slog"
 	"net/url"
+	"org-service/helper"
 	"org-service/middleware"
 	"strconv"
 
-	"github.com/gofiber/fiber/v2/log"
-
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -15,15 +16,28 @@ type UserHTTPTransport interface {
 	ChangeUserStatus(c *fiber.Ctx) error
 	InviteUser(c *fiber.Ctx) error
 	AcceptInvitation(c *fiber.Ctx) error
+	AcceptInvitationAsUser(c *fiber.Ctx) error
+	GetInvitation(c *fiber.Ctx) error
+	VerifyEmail(c *fiber.Ctx) error
+	ResendVerificationEmail(c *fiber.Ctx) error
+	GetMe(c *fiber.Ctx) error
+	UpdateMe(c *fiber.Ctx) error
+	ChangePassword(c *fiber.Ctx) error
+	ChangeEmail(c *fiber.Ctx) error
+	ConfirmEmailChange(c *fiber.Ctx) error
+	UploadAvatar(c *fiber.Ctx) error
+	DeleteAvatar(c *fiber.Ctx) error
+	ListSessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
 }
 
 type userHTTPTransport struct {
 	userApi UserAPI
-	logger *log.AllLogger
+	logger *slog.Logger
 }
 
-func NewUserHTTPTransport(userApi UserAPI) UserHTTPTransport {
-	return &userHTTPTransport{userApi: userApi}
+func NewUserHTTPTransport(userApi UserAPI, logger *slog.Logger) UserHTTPTransport {
+	return &userHTTPTransport{userApi: userApi, logger: logger}
 }
 
 
@@ -32,12 +46,14 @@ func (s *userHTTPTransport) ChangeUserRole(c *fiber.Ctx) error {
 	req.OrgID = middleware.CtxOrgID(c)
 
 	if err := c.BodyParser(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return helper.ValidationError("invalid_body", "Invalid request body")
 	}
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
 
-	resp, err := s.userApi.ChangeUserRole(req)
+	resp, err := s.userApi.ChangeUserRole(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
@@ -48,12 +64,17 @@ func (s *userHTTPTransport) ChangeUserStatus(c *fiber.Ctx) error {
 	req := &ChangeUserStatusRequest{}
 	req.OrgID = middleware.CtxOrgID(c)
 	if err := c.BodyParser(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	req.CurrentUserID, _ = middleware.CtxUserID(c)
+	req.CurrentRoleID = middleware.CtxRoleID(c)
+	if key := middleware.CtxAPIKey(c); key != nil {
+		req.APIKeyID = key.ID
 	}
 
-	resp, err := s.userApi.ChangeUserStatus(req)
+	resp, err := s.userApi.ChangeUserStatus(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
@@ -66,25 +87,27 @@ func (s *userHTTPTransport) InviteUser(c *fiber.Ctx) error {
 	req.Email = c.Params("email")
 	decodedEmail, err := url.QueryUnescape(req.Email)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return helper.FieldInvalid("email", "is not a valid URL-encoded value")
 	}
 	req.Email = decodedEmail
-	roleIdParam := c.Params("roleId")
-	roleId, err := strconv.Atoi(roleIdParam)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	// Without a roleId the org's default role is used.
+	if roleIdParam := c.Params("roleId"); roleIdParam != "" {
+		roleId, err := strconv.Atoi(roleIdParam)
+		if err != nil {
+			return helper.FieldInvalid("roleId", "must be a number")
+		}
+		req.RoleID = roleId
 	}
-	req.RoleID = roleId
 	userId, err := middleware.CtxUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	req.CurrentUserID = userId
 	req.CurrentRoleID = middleware.CtxRoleID(c)
 
-	resp, err := s.userApi.InviteUser(req)
+	resp, err := s.userApi.InviteUser(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
@@ -93,15 +116,241 @@ func (s *userHTTPTransport) InviteUser(c *fiber.Ctx) error {
 func (s *userHTTPTransport) AcceptInvitation(c *fiber.Ctx) error {
 	req := &AcceptInvitationRequest{}
 	if err := c.BodyParser(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+
+	req.Token = c.Params("token")
+
+	resp, err := s.userApi.AcceptInvitation(c.UserContext(), req)
+	if err != nil {
+		return err
 	}
 
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) AcceptInvitationAsUser(c *fiber.Ctx) error {
+	req := &AcceptInvitationAsUserRequest{}
 	req.Token = c.Params("token")
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.AcceptInvitationAsUser(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) GetInvitation(c *fiber.Ctx) error {
+	req := &InvitationRequest{}
+	req.Token = c.Params("token")
+
+	resp, err := s.userApi.GetInvitation(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) VerifyEmail(c *fiber.Ctx) error {
+	req := &VerifyEmailRequest{}
+	req.Token = c.Params("token")
+
+	resp, err := s.userApi.VerifyEmail(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
 
-	resp, err := s.userApi.AcceptInvitation(req)
+func (s *userHTTPTransport) ResendVerificationEmail(c *fiber.Ctx) error {
+	req := &ResendVerificationRequest{}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.ResendVerificationEmail(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) GetMe(c *fiber.Ctx) error {
+	req := &MeRequest{}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.GetMe(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
-}
\ No newline at end of file
+}
+
+func (s *userHTTPTransport) UpdateMe(c *fiber.Ctx) error {
+	req := &UpdateProfileRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.UpdateMe(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) ChangePassword(c *fiber.Ctx) error {
+	req := &ChangePasswordRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.ChangePassword(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) ChangeEmail(c *fiber.Ctx) error {
+	req := &ChangeEmailRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return helper.ValidationError("invalid_body", "Invalid request body")
+	}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.ChangeEmail(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) ConfirmEmailChange(c *fiber.Ctx) error {
+	req := &ConfirmEmailChangeRequest{}
+	req.Token = c.Params("token")
+
+	resp, err := s.userApi.ConfirmEmailChange(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) UploadAvatar(c *fiber.Ctx) error {
+	req := &UploadAvatarRequest{}
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return helper.FieldRequired("avatar")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	req.Image, err = io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.UploadAvatar(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) DeleteAvatar(c *fiber.Ctx) error {
+	req := &MeRequest{}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+
+	resp, err := s.userApi.DeleteAvatar(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) ListSessions(c *fiber.Ctx) error {
+	req := &SessionsRequest{}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	req.CurrentUserID = userId
+	req.CurrentSessionID = middleware.CtxSessionID(c)
+
+	resp, err := s.userApi.ListSessions(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *userHTTPTransport) RevokeSession(c *fiber.Ctx) error {
+	req := &RevokeSessionRequest{}
+	userId, err := middleware.CtxUserID(c)
+	if err != nil {
+		return err
+	}
+	sessionId, err := strconv.Atoi(c.Params("sessionId"))
+	if err != nil {
+		return helper.FieldInvalid("sessionId", "must be a number")
+	}
+	req.CurrentUserID = userId
+	req.SessionID = sessionId
+	req.CurrentSessionID = middleware.CtxSessionID(c)
+
+	resp, err := s.userApi.RevokeSession(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}